@@ -1,22 +1,26 @@
 package blog
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/fs"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/titpetric/platform"
-	yaml "gopkg.in/yaml.v3"
 
+	"github.com/titpetric/platform-example/blog/markdown"
 	"github.com/titpetric/platform-example/blog/model"
 	"github.com/titpetric/platform-example/blog/storage"
+	"github.com/titpetric/platform-example/blog/view"
 )
 
 // Module implements the blog module for the platform
@@ -32,8 +36,42 @@ type Module struct {
 	// Articles index for in-memory access
 	articles map[string]*model.Article
 
+	// Known markdown files and their state, used for incremental reindexing
+	files map[string]fileState
+
+	// mu guards articles and files
+	mu sync.Mutex
+
+	// stopWatch cancels the data directory watcher started in Start
+	stopWatch context.CancelFunc
+
+	// watchDone is closed when the watcher goroutine exits
+	watchDone chan struct{}
+
 	// Theme fs that combines embedded theme and live theme/ folder.
 	themeFS fs.FS
+
+	// theme is the version of themeFS, refreshed by the watcher
+	theme *themeVersion
+
+	// preview makes drafts and scheduled articles visible
+	preview bool
+
+	// render renders article bodies, caching the HTML by content hash
+	render *renderCache
+
+	// renderVersion is the renderer version the stored HTML was rendered
+	// with, guarded by mu
+	renderVersion string
+
+	// shortcodes render theme components embedded in articles
+	shortcodes *themeShortcodes
+
+	// persistRenderCache stores rendered article HTML in the database
+	persistRenderCache bool
+
+	// redirectsFile is the site redirects file, relative to the working directory
+	redirectsFile string
 }
 
 // NewModule creates a new blog module instance
@@ -43,15 +81,24 @@ func NewModule(dataDir string) *Module {
 
 	// Check if local theme directory exists
 	var overlay fs.FS = themeSub
-	if _, err := os.Stat("theme"); err == nil {
-		overlay = NewOverlayFS(os.DirFS("theme"), themeSub)
+	if _, err := os.Stat(themeDir); err == nil {
+		overlay = NewOverlayFS(os.DirFS(themeDir), themeSub)
 	}
-
-	return &Module{
-		dataDir:  dataDir,
-		themeFS:  overlay,
-		articles: make(map[string]*model.Article),
+	theme := newThemeVersion(overlay)
+
+	m := &Module{
+		dataDir:    dataDir,
+		themeFS:    overlay,
+		theme:      theme,
+		articles:   make(map[string]*model.Article),
+		files:      make(map[string]fileState),
+		render:     newRenderCache(),
+		shortcodes: newThemeShortcodes(theme),
+
+		redirectsFile: redirectsFile,
 	}
+	m.SetMarkdownExtensions()
+	return m
 }
 
 // Name returns the module name
@@ -62,7 +109,7 @@ func (m *Module) Name() string {
 // Mount registers the blog routes with the router
 func (m *Module) Mount(_ context.Context, r platform.Router) error {
 	// Create handlers using the module's storage
-	h, err := NewHandlers(m.repository, m.themeFS)
+	h, err := newHandlers(m.repository, m.theme)
 	if err != nil {
 		return err
 	}
@@ -73,7 +120,10 @@ func (m *Module) Mount(_ context.Context, r platform.Router) error {
 	r.Group(func(r platform.Router) {
 		// r.Use(user.Middleware)
 
-		// Static files
+		// Static files, generated stylesheets take precedence over the theme
+		for _, sheet := range h.views.SyntaxStylesheets() {
+			r.Get(sheet.Href(), h.GetStylesheet(sheet))
+		}
 		r.Get("/assets/css/*", func(w http.ResponseWriter, r *http.Request) { assetFS.ServeHTTP(w, r) })
 		r.Get("/assets/fonts/*", func(w http.ResponseWriter, r *http.Request) { assetFS.ServeHTTP(w, r) })
 		r.Get("/assets/icons/*", func(w http.ResponseWriter, r *http.Request) { assetFS.ServeHTTP(w, r) })
@@ -85,17 +135,29 @@ func (m *Module) Mount(_ context.Context, r platform.Router) error {
 		r.Get("/api/blog/articles", h.ListArticlesJSON)
 		r.Get("/api/blog/articles/{slug}", h.GetArticleJSON)
 		r.Get("/api/blog/search", h.SearchArticlesJSON)
+		r.Get("/api/blog/tags", h.ListTagsJSON)
+		r.Get("/api/blog/tags/{tag}", h.GetTagJSON)
 
 		// HTML Routes
 		r.Get("/", h.IndexHTML)
 		r.Get("/blog/", h.ListArticlesHTML)
+		r.Get("/blog/page/{page}", h.ListArticlesHTML)
+		r.Get("/blog/page/{page}/", h.ListArticlesHTML)
 		r.Get("/blog/{slug}", h.GetArticleHTML)
 		r.Get("/blog/{slug}/", h.GetArticleHTML)
+		r.Get("/blog/tags/", h.ListTagsHTML)
+		r.Get("/blog/tags/{tag}", h.GetTagHTML)
+		r.Get("/blog/tags/{tag}/", h.GetTagHTML)
 
 		// Feed Routes
 		r.Get("/feed.xml", h.GetAtomFeed)
+		r.Get("/sitemap.xml", h.GetSitemap)
+		r.Get("/blog/tags/{tag}/feed.xml", h.GetTagFeed)
 	})
 
+	// Theme pages and redirects are looked up for requests without a route
+	r.NotFound(h.NotFound)
+
 	return nil
 }
 
@@ -108,7 +170,7 @@ func (m *Module) Start(ctx context.Context) error {
 	}
 
 	// Create storage instance
-	m.repository = storage.NewStorage(db)
+	m.SetRepository(storage.NewStorage(db))
 
 	// Create schema
 	if err := m.repository.InitSchema(ctx); err != nil {
@@ -130,18 +192,67 @@ func (m *Module) Start(ctx context.Context) error {
 	}
 	fmt.Printf("[blog] verified %d articles in database\n", total)
 
+	// Watch the data directory for changes
+	watchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.stopWatch = cancel
+	m.watchDone = make(chan struct{})
+	go func() {
+		defer close(m.watchDone)
+		m.watch(watchCtx)
+	}()
+
 	return nil
 }
 
 // Stop is called when the module is shutting down
-func (m *Module) Stop(context.Context) error {
-	// Nothing to clean up - database is managed by platform
+func (m *Module) Stop(ctx context.Context) error {
+	// Database is managed by platform, only the watcher needs to stop
+	if m.stopWatch == nil {
+		return nil
+	}
+
+	m.stopWatch()
+
+	select {
+	case <-m.watchDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	return nil
 }
 
 // SetRepository sets the repository on the module
 func (m *Module) SetRepository(repo *storage.Storage) {
 	m.repository = repo
+	m.repository.SetPreview(m.preview)
+	m.SetPersistentRenderCache(m.persistRenderCache)
+}
+
+// SetPreview enables preview mode, in which drafts and scheduled
+// articles are listed and served like published articles
+func (m *Module) SetPreview(preview bool) {
+	m.preview = preview
+	if m.repository != nil {
+		m.repository.SetPreview(preview)
+	}
+}
+
+// SetPersistentRenderCache enables storing rendered article HTML in the
+// database, so a cold start doesn't render every article again
+func (m *Module) SetPersistentRenderCache(enabled bool) {
+	m.persistRenderCache = enabled
+	m.render.repository = nil
+	if enabled {
+		m.render.repository = m.repository
+	}
+}
+
+// SetMarkdownExtensions sets the extensions of the markdown renderer, like
+// transforms of the article AST. Set them before scanning the articles.
+// Shortcodes of theme components are always enabled.
+func (m *Module) SetMarkdownExtensions(extensions ...markdown.Extension) {
+	extensions = append([]markdown.Extension{m.shortcodes.Extension()}, extensions...)
+	m.render.renderer = markdown.NewRenderer(extensions...)
 }
 
 // ScanMarkdownFiles scans the data directory for markdown files and indexes them
@@ -153,40 +264,234 @@ func (m *Module) ScanMarkdownFiles(ctx context.Context) (int, error) {
 // scanMarkdownFiles scans the data directory for markdown files and indexes them
 // Returns the count of scanned files
 func (m *Module) scanMarkdownFiles(ctx context.Context) (int, error) {
+	files, err := m.listSourceFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := m.indexRedirects(ctx); err != nil {
+		return 0, err
+	}
+
+	version := m.render.renderer.Version()
+	cascade := newDataCascade(m.dataDir)
 	count := 0
-	err := filepath.WalkDir(m.dataDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
+	for _, path := range sortedKeys(files) {
+		if isMarkdownFile(path) {
+			count++
+
+			if err := m.indexFile(ctx, cascade, path); err != nil {
+				return count, err
+			}
 		}
 
-		// Only process markdown files
-		if d.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
+		m.mu.Lock()
+		m.files[path] = files[path]
+		m.mu.Unlock()
+	}
+
+	if err := m.removeMissingFiles(ctx, files); err != nil {
+		return count, err
+	}
+
+	m.mu.Lock()
+	m.renderVersion = version
+	m.mu.Unlock()
+
+	// The cache is an optimization, scanning succeeded regardless
+	if err := m.pruneRenderCache(ctx); err != nil {
+		log.Printf("[blog] can't prune rendered html: %v", err)
+	}
+	return count, nil
+}
+
+// pruneRenderCache removes stored HTML that no indexed article renders to
+func (m *Module) pruneRenderCache(ctx context.Context) error {
+	m.mu.Lock()
+	contents := make([][]byte, 0, len(m.articles))
+	for _, article := range m.articles {
+		contents = append(contents, []byte(article.BodyMarkdown))
+	}
+	m.mu.Unlock()
+
+	_, err := m.render.Prune(ctx, contents)
+	return err
+}
+
+// removeMissingFiles removes the stored articles of files that aren't in
+// files, like files deleted while the module wasn't running
+func (m *Module) removeMissingFiles(ctx context.Context, files map[string]fileState) error {
+	filenames, err := m.repository.GetArticleFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list stored articles: %w", err)
+	}
+
+	for _, filename := range filenames {
+		if _, ok := files[filename]; ok {
+			continue
+		}
+		if err := m.removeFile(ctx, filename); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		count++
+// isMarkdownFile returns true if path is a markdown article
+func isMarkdownFile(path string) bool {
+	return strings.HasSuffix(path, ".md")
+}
 
-		article, err := m.parseMarkdownFile(path)
+// listSourceFiles walks the data directory and returns the state of
+// every markdown file and data file
+func (m *Module) listSourceFiles() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(m.dataDir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
-			return fmt.Errorf("failed to parse %s: %w", path, err)
+			return err
 		}
 
-		// Store in memory map
-		m.articles[article.Slug] = article
+		// Only process markdown and data files
+		if d.IsDir() || !(isMarkdownFile(path) || isDataFile(path)) {
+			return nil
+		}
 
-		// Insert into database
-		err = m.repository.InsertArticle(ctx, article)
+		info, err := d.Info()
 		if err != nil {
-			return fmt.Errorf("failed to insert article %s: %w", article.Slug, err)
+			return err
 		}
 
+		files[path] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
 		return nil
 	})
-	return count, err
+	return files, err
 }
 
-// parseMarkdownFile parses a markdown file and extracts metadata
-func (m *Module) parseMarkdownFile(filePath string) (*model.Article, error) {
+// indexFile parses a markdown file and upserts the article into memory and
+// storage, with the data files of cascade
+func (m *Module) indexFile(ctx context.Context, cascade *dataCascade, path string) error {
+	doc, err := m.parseMarkdownFile(cascade, path)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	article := doc.Article
+
+	// Two files of the data directory can't be the same article or share a slug
+	if err := m.checkIdentity(article); err != nil {
+		return err
+	}
+
+	// Routes of the module take precedence over permalinks
+	if err := newPermalinks().Claim(article.URL, path); err != nil {
+		return err
+	}
+
+	// Store the content, so readers don't depend on the data directory
+	article.BodyMarkdown = string(doc.Body)
+	body := m.render.RenderTOC(ctx, doc.Body)
+	article.BodyHTML = string(body.HTML)
+	article.ContentHash = contentHash(doc.Body)
+
+	// The table of contents is on unless the front matter turns it off
+	if toc := doc.Metadata.TOC; toc == nil || *toc {
+		article.TOC = body.TOC
+	}
+
+	text := markdown.Text(doc.Body)
+	article.WordCount = int64(len(strings.Fields(text)))
+
+	// Insert into database
+	if err := m.repository.InsertArticle(ctx, article); err != nil {
+		return fmt.Errorf("failed to insert article %s: %w", article.Slug, err)
+	}
+
+	// Index the article body for full-text search
+	if err := m.repository.IndexArticleBody(ctx, article.Slug, text); err != nil {
+		return fmt.Errorf("failed to index article %s: %w", article.Slug, err)
+	}
+
+	// Assign tags from front matter
+	if err := m.repository.SetArticleTags(ctx, article.ID, doc.Metadata.Tags); err != nil {
+		return fmt.Errorf("failed to tag article %s: %w", article.Slug, err)
+	}
+
+	// Redirect previous paths of the article to it
+	aliases := append(append([]string{}, doc.Metadata.Aliases...), doc.Metadata.RedirectFrom...)
+	if err := m.repository.SetArticleRedirects(ctx, article.ID, article.URL, aliases); err != nil {
+		return fmt.Errorf("failed to store aliases of article %s: %w", article.Slug, err)
+	}
+
+	// Store in memory map, replacing the article of the file under a previous slug
+	m.mu.Lock()
+	for slug, other := range m.articles {
+		if other.Filename == article.Filename {
+			delete(m.articles, slug)
+		}
+	}
+	m.articles[article.Slug] = article
+	m.mu.Unlock()
+
+	return nil
+}
+
+// checkIdentity returns an error if another file is indexed as the same
+// article, or with the same slug. The article of a file that no longer
+// exists was moved, and is taken over.
+func (m *Module) checkIdentity(article *model.Article) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.articles {
+		if other.Filename == article.Filename || (other.ID != article.ID && other.Slug != article.Slug) {
+			continue
+		}
+		if _, err := os.Stat(other.Filename); err != nil {
+			continue
+		}
+		if other.ID == article.ID {
+			return fmt.Errorf("%s and %s are both article %s", other.Filename, article.Filename, article.ID)
+		}
+		return fmt.Errorf("%s and %s both have the slug %s", other.Filename, article.Filename, article.Slug)
+	}
+	return nil
+}
+
+// removeFile removes the article sourced from path from memory and storage.
+// An article moved to another file keeps its ID and is stored with the new
+// file name, so it isn't removed.
+func (m *Module) removeFile(ctx context.Context, path string) error {
+	m.mu.Lock()
+	for slug, article := range m.articles {
+		if article.Filename == path {
+			delete(m.articles, slug)
+		}
+	}
+	m.mu.Unlock()
+
+	if err := m.repository.DeleteArticleFile(ctx, path); err != nil {
+		return fmt.Errorf("failed to delete article %s: %w", path, err)
+	}
+	return nil
+}
+
+// document is a parsed markdown file
+type document struct {
+	// Article is the article row for storage
+	Article *model.Article
+
+	// Metadata is the decoded front matter
+	Metadata model.Metadata
+
+	// Body is the markdown content without front matter
+	Body []byte
+}
+
+// parseMarkdownFile parses a markdown file and extracts metadata, merged
+// with the data files of cascade
+func (m *Module) parseMarkdownFile(cascade *dataCascade, filePath string) (*document, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
@@ -195,28 +500,39 @@ func (m *Module) parseMarkdownFile(filePath string) (*model.Article, error) {
 	content := string(data)
 
 	// Extract YAML front matter
-	var meta model.Metadata
+	var frontMatter []byte
 
 	// Check if file starts with ---
 	if strings.HasPrefix(content, "---") {
 		parts := strings.SplitN(content, "---", 3)
 		if len(parts) >= 3 {
-			if err := yaml.Unmarshal([]byte(parts[1]), &meta); err != nil {
-				return nil, fmt.Errorf("failed to parse YAML front matter: %w", err)
-			}
+			frontMatter = []byte(parts[1])
 		}
 	}
 
+	// Merge directory data files with the front matter
+	meta, err := cascade.apply(filePath, frontMatter)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate article ID and slug
 	fileName := filepath.Base(filePath)
 	slug := strings.TrimSuffix(fileName, filepath.Ext(fileName))
-	id := generateID(slug)
+	id := generateID(slug, meta.ID)
 	now := time.Now()
 
-	// Parse date
-	var stamp *time.Time
-	if metaDate, err := time.Parse("2006-01-02", meta.Date); err == nil {
-		stamp = &metaDate
+	// Parse date, falling back to the file modification time so an
+	// undated article keeps a stable date between scans
+	stamp, err := parseDate(meta.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse date %q: %w", meta.Date, err)
+	}
+	if stamp == nil {
+		if info, err := os.Stat(filePath); err == nil {
+			modTime := info.ModTime()
+			stamp = &modTime
+		}
 	}
 
 	// Set default layout if not provided
@@ -235,15 +551,57 @@ func (m *Module) parseMarkdownFile(filePath string) (*model.Article, error) {
 		OgImage:     meta.OgImage,
 		Layout:      layout,
 		Source:      meta.Source,
-		URL:         "/blog/" + slug + "/",
+		Draft:       meta.Draft,
+		Unlisted:    meta.Unlisted,
 		CreatedAt:   &now,
 		UpdatedAt:   &now,
 	}
 
-	return article, nil
+	article.URL, err = articlePath(meta.Permalink, article)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{
+		Article:  article,
+		Metadata: meta,
+		Body:     bytes.TrimLeft(view.StripFrontMatter(data), "\r\n"),
+	}, nil
+}
+
+// dateLayouts are the accepted formats for the front matter date
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// parseDate parses a front matter date, returning nil if the date is empty.
+// A time can be given to schedule an article for later in the day. Dates
+// without a time zone are in the local time zone, like the file times
+// undated articles fall back to.
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var err error
+	for _, layout := range dateLayouts {
+		var stamp time.Time
+		if stamp, err = time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &stamp, nil
+		}
+	}
+	return nil, err
 }
 
-// generateID creates a unique ID from slug
-func generateID(slug string) string {
-	return slug + "-" + time.Now().Format("20060102150405")
+// generateID returns the article ID, which stays the same between scans.
+// It's the front matter `id` if set, so the article keeps its identity
+// when the file is renamed, and the slug otherwise.
+func generateID(slug, id string) string {
+	if id = strings.TrimSpace(id); id != "" {
+		return id
+	}
+	return slug
 }
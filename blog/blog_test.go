@@ -0,0 +1,171 @@
+package blog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleIndexContent(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	content := "---\ntitle: Content\ndate: 2024-01-15\n---\n\n# Heading\n\nSome *formatted* text here.\n"
+	path := filepath.Join(dataDir, "content.md")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	// The database is the source of truth once articles are indexed
+	require.NoError(t, os.Remove(path))
+
+	article, err := m.repository.GetArticleBySlug(ctx, "content")
+	require.NoError(t, err)
+	require.Equal(t, "# Heading\n\nSome *formatted* text here.\n", article.BodyMarkdown)
+	require.Contains(t, article.BodyHTML, "<em>formatted</em>")
+	require.EqualValues(t, 5, article.WordCount)
+	require.Equal(t, contentHash([]byte(article.BodyMarkdown)), article.ContentHash)
+
+	t.Run("content hash follows the body", func(t *testing.T) {
+		writeArticle(t, path, "Content", time.Now())
+		require.NoError(t, m.indexFile(ctx, newDataCascade(dataDir), path))
+
+		updated, err := m.repository.GetArticleBySlug(ctx, "content")
+		require.NoError(t, err)
+		require.NotEqual(t, article.ContentHash, updated.ContentHash)
+		require.Equal(t, "Body of Content\n", updated.BodyMarkdown)
+	})
+
+	t.Run("table of contents", func(t *testing.T) {
+		require.Equal(t, `[{"id":"heading","title":"Heading","level":1}]`, article.TOC)
+
+		content := "---\ntitle: Content\ndate: 2024-01-15\ntoc: false\n---\n\n# Heading\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		require.NoError(t, m.indexFile(ctx, newDataCascade(dataDir), path))
+
+		updated, err := m.repository.GetArticleBySlug(ctx, "content")
+		require.NoError(t, err)
+		require.Empty(t, updated.TOC)
+		require.Contains(t, updated.BodyHTML, `<h1 id="heading">Heading</h1>`)
+	})
+}
+
+func TestModuleArticleIdentity(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	stamp := time.Now().Add(-time.Hour)
+
+	content := "---\nid: identity\ntitle: Identity\ndate: 2024-01-15\n---\n\nBody\n"
+	writeFile(t, filepath.Join(dataDir, "old-name.md"), content)
+	writeArticle(t, filepath.Join(dataDir, "plain.md"), "Plain", stamp)
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	article, err := m.repository.GetArticleBySlug(ctx, "old-name")
+	require.NoError(t, err)
+	require.Equal(t, "identity", article.ID)
+
+	plain, err := m.repository.GetArticleBySlug(ctx, "plain")
+	require.NoError(t, err)
+	require.Equal(t, "plain", plain.ID)
+
+	t.Run("scanning again keeps the article", func(t *testing.T) {
+		_, err := m.ScanMarkdownFiles(ctx)
+		require.NoError(t, err)
+
+		again, err := m.repository.GetArticleBySlug(ctx, "old-name")
+		require.NoError(t, err)
+		require.Equal(t, article.ID, again.ID)
+		require.True(t, article.CreatedAt.Equal(*again.CreatedAt))
+		require.True(t, article.UpdatedAt.Equal(*again.UpdatedAt))
+	})
+
+	t.Run("renamed file redirects", func(t *testing.T) {
+		require.NoError(t, os.Rename(filepath.Join(dataDir, "old-name.md"), filepath.Join(dataDir, "new-name.md")))
+
+		updated, removed, err := m.reindex(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, updated)
+		require.Equal(t, 1, removed)
+
+		renamed, err := m.repository.GetArticleBySlug(ctx, "new-name")
+		require.NoError(t, err)
+		require.Equal(t, "identity", renamed.ID)
+		require.True(t, article.CreatedAt.Equal(*renamed.CreatedAt))
+
+		h := &Handlers{repository: m.repository}
+		router := chi.NewRouter()
+		router.Get("/blog/{slug}/", h.GetArticleHTML)
+		router.Get("/api/blog/articles/{slug}", h.GetArticleJSON)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blog/old-name/", nil))
+		require.Equal(t, http.StatusMovedPermanently, w.Code)
+		require.Equal(t, "/blog/new-name/", w.Header().Get("Location"))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/blog/articles/old-name", nil))
+		require.Equal(t, http.StatusMovedPermanently, w.Code)
+		require.Equal(t, "/api/blog/articles/new-name", w.Header().Get("Location"))
+	})
+}
+
+func TestModuleDuplicateArticles(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	stamp := time.Now().Add(-time.Hour)
+
+	// The same file name in different directories is the same article ID
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "2024"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "2025"), 0o755))
+	writeArticle(t, filepath.Join(dataDir, "2024", "hello.md"), "Hello 2024", stamp)
+	writeArticle(t, filepath.Join(dataDir, "2025", "hello.md"), "Hello 2025", stamp)
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.ErrorContains(t, err, "2024/hello.md and "+filepath.Join(dataDir, "2025", "hello.md")+" are both article hello")
+
+	article, err := m.repository.GetArticleBySlug(ctx, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "Hello 2024", article.Title, "the first article isn't replaced")
+
+	t.Run("same slug", func(t *testing.T) {
+		// Different IDs don't make different slugs
+		writeFile(t, filepath.Join(dataDir, "2025", "hello.md"), "---\nid: hello-2025\ntitle: Hello 2025\ndate: 2024-01-15\n---\n\nBody\n")
+
+		_, err := m.ScanMarkdownFiles(ctx)
+		require.ErrorContains(t, err, "both have the slug hello")
+
+		article, err := m.repository.GetArticleBySlug(ctx, "hello")
+		require.NoError(t, err)
+		require.Equal(t, "Hello 2024", article.Title, "the first article isn't deleted")
+	})
+}
+
+func TestParseDate(t *testing.T) {
+	stamp, err := parseDate("2025-03-01 09:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local), *stamp, "dates without a zone are local")
+
+	stamp, err = parseDate("2025-03-01T09:00:00Z")
+	require.NoError(t, err)
+	require.True(t, stamp.Equal(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)))
+
+	stamp, err = parseDate("")
+	require.NoError(t, err)
+	require.Nil(t, stamp)
+
+	_, err = parseDate("March 1st")
+	require.Error(t, err)
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/titpetric/platform"
 	"github.com/titpetric/platform-app/modules/user"
 
 	"github.com/titpetric/platform-example/blog"
+	"github.com/titpetric/platform-example/blog/markdown"
 )
 
 func main() {
@@ -24,7 +26,12 @@ func start(ctx context.Context) error {
 
 	svc.Use(middleware.Logger)
 	svc.Register(user.NewHandler())
-	svc.Register(blog.NewModule("./data"))
+
+	module := blog.NewModule("./data")
+	module.SetPreview(os.Getenv("BLOG_PREVIEW") == "true")
+	module.SetPersistentRenderCache(os.Getenv("BLOG_RENDER_CACHE") == "true")
+	module.SetMarkdownExtensions(markdown.HeadingAnchors())
+	svc.Register(module)
 
 	if err := svc.Start(ctx); err != nil {
 		return err
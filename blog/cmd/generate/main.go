@@ -5,41 +5,76 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/titpetric/platform-example/blog"
+	"github.com/titpetric/platform-example/blog/markdown"
 	"github.com/titpetric/platform-example/blog/storage"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serveMain(os.Args[2:])
+		return
+	}
+
 	outputDir := flag.String("output", "public", "Output directory for generated files")
 	dataDir := flag.String("data", "data", "Data directory for markdown files")
+	preview := flag.Bool("preview", false, "Include drafts and scheduled articles")
+	renderCache := flag.Bool("render-cache", false, "Keep rendered article HTML in the database between runs")
+	redirectsFile := flag.Bool("redirects-file", false, "Write redirects to a _redirects file for static hosts")
+	incremental := flag.Bool("incremental", false, "Only render files whose inputs changed since the last run")
+	jobs := flag.Int("j", runtime.NumCPU(), "Number of files rendered at the same time")
 	flag.Parse()
 
 	ctx := context.Background()
 
 	// Initialize platform (database only)
-	if err := generate(ctx, *dataDir, *outputDir); err != nil {
+	if err := generate(ctx, *dataDir, *outputDir, *preview, *renderCache, *redirectsFile, *incremental, *jobs); err != nil {
 		log.Fatalf("generation failed: %v", err)
 	}
 }
 
-func generate(ctx context.Context, dataDir, outputDir string) error {
-	start := time.Now()
+// serveMain runs `generate serve`, the dev server with live rebuilds
+func serveMain(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "Address to serve the site on")
+	outputDir := flags.String("output", "", "Output directory for generated files (default a temporary directory)")
+	dataDir := flags.String("data", "data", "Data directory for markdown files")
+	preview := flags.Bool("preview", false, "Include drafts and scheduled articles")
+	jobs := flags.Int("j", runtime.NumCPU(), "Number of files rendered at the same time")
+	flags.Parse(args)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serve(ctx, *addr, *dataDir, *outputDir, *preview, *jobs); err != nil {
+		log.Fatalf("serve failed: %v", err)
+	}
+}
+
+// setup creates the module over dataDir and indexes the markdown files
+func setup(ctx context.Context, dataDir string, preview, renderCache bool) (*blog.Module, error) {
 	// Get database from platform
 	db, err := storage.DB(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
+		return nil, fmt.Errorf("failed to get database: %w", err)
 	}
 
 	// Create module and load articles
 	module := blog.NewModule(dataDir)
+	module.SetPreview(preview)
+	module.SetPersistentRenderCache(renderCache)
+	module.SetMarkdownExtensions(markdown.HeadingAnchors())
 
 	// Create storage and schema
 	repo := storage.NewStorage(db)
 	if err := repo.InitSchema(ctx); err != nil {
-		return fmt.Errorf("failed to initialize schema: %w", err)
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
 	// Set repository on module
@@ -48,12 +83,26 @@ func generate(ctx context.Context, dataDir, outputDir string) error {
 	// Scan markdown files
 	count, err := module.ScanMarkdownFiles(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to scan markdown files: %w", err)
+		return nil, fmt.Errorf("failed to scan markdown files: %w", err)
 	}
 	fmt.Printf("Scanned %d markdown files\n", count)
 
+	return module, nil
+}
+
+func generate(ctx context.Context, dataDir, outputDir string, preview, renderCache, redirectsFile, incremental bool, jobs int) error {
+	start := time.Now()
+
+	module, err := setup(ctx, dataDir, preview, renderCache)
+	if err != nil {
+		return err
+	}
+
 	// Generate static files
 	gen := blog.NewGenerator(module, outputDir)
+	gen.SetRedirectsFile(redirectsFile)
+	gen.SetIncremental(incremental)
+	gen.SetConcurrency(jobs)
 	if err := gen.Generate(ctx); err != nil {
 		return fmt.Errorf("generation failed: %w", err)
 	}
@@ -63,3 +112,23 @@ func generate(ctx context.Context, dataDir, outputDir string) error {
 	fmt.Println("✓ Completed in", duration)
 	return nil
 }
+
+func serve(ctx context.Context, addr, dataDir, outputDir string, preview bool, jobs int) error {
+	if outputDir == "" {
+		tempDir, err := os.MkdirTemp("", "blog-serve-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+		outputDir = tempDir
+	}
+
+	module, err := setup(ctx, dataDir, preview, false)
+	if err != nil {
+		return err
+	}
+
+	gen := blog.NewGenerator(module, outputDir)
+	gen.SetConcurrency(jobs)
+	return gen.Serve(ctx, addr)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/titpetric/platform-example/blog/storage"
+)
+
+func main() {
+	rollback := flag.Int("rollback", 0, "Number of applied migrations to roll back")
+	status := flag.Bool("status", false, "List applied migrations")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	if err := migrate(ctx, *rollback, *status); err != nil {
+		log.Fatalf("migration failed: %v", err)
+	}
+}
+
+func migrate(ctx context.Context, rollback int, status bool) error {
+	db, err := storage.DB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database: %w", err)
+	}
+
+	repo := storage.NewStorage(db)
+
+	switch {
+	case status:
+		migrations, err := repo.GetMigrations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			fmt.Printf("%s\t%d statements\t%s\t%s\n", migration.Filename, migration.StatementIndex, migration.Status, migration.Checksum)
+		}
+	case rollback > 0:
+		count, err := repo.Rollback(ctx, rollback)
+		fmt.Printf("Rolled back %d migrations\n", count)
+		return err
+	default:
+		if err := repo.InitSchema(ctx); err != nil {
+			return err
+		}
+		fmt.Println("✓ Schema is up to date")
+	}
+
+	return nil
+}
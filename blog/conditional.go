@@ -0,0 +1,156 @@
+package blog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// validator identifies the version of a response for conditional requests
+type validator struct {
+	// ETag is the quoted strong entity tag
+	ETag string
+
+	// Modified is the last modification time, zero if unknown
+	Modified time.Time
+}
+
+// hashFS hashes the names and contents of all files in fsys
+func hashFS(fsys fs.FS) (string, error) {
+	hash := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		f, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		io.WriteString(hash, path+"\x00")
+		_, err = io.Copy(hash, f)
+		return err
+	})
+	return hex.EncodeToString(hash.Sum(nil)), err
+}
+
+// etag returns a strong entity tag for the response identified by parts
+func (h *Handlers) etag(parts ...string) string {
+	var version string
+	if h.theme != nil {
+		version = h.theme.Get()
+	}
+	hash := sha256.Sum256([]byte(version + "\x00" + strings.Join(parts, "\x00")))
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// articlesValidator returns the validator for a response of format listing articles.
+// Extra parts identify anything else the response depends on, e.g. the page.
+// Lists have no modification time, as the articles they no longer list
+// don't have one, so only the ETag changes when an article is removed.
+func (h *Handlers) articlesValidator(format string, articles []model.Article, extra ...any) validator {
+	parts := []string{format, fmt.Sprintf("%v", extra)}
+	for _, article := range articles {
+		parts = append(parts, articleVersion(&article))
+	}
+
+	return validator{
+		ETag: h.etag(parts...),
+	}
+}
+
+// articleValidator returns the validator for a response of format with a
+// single article, modified when the article was last indexed or published
+func (h *Handlers) articleValidator(format string, article *model.Article) validator {
+	// A scheduled article is modified when it gets published
+	modified := latest(latest(time.Time{}, article.UpdatedAt), article.Date)
+	if now := time.Now(); modified.After(now) {
+		modified = now
+	}
+
+	return validator{
+		ETag:     h.articlesValidator(format, []model.Article{*article}).ETag,
+		Modified: modified,
+	}
+}
+
+// tagsValidator returns the validator for a response of format listing tags
+func (h *Handlers) tagsValidator(format string, tags []model.TagCount) validator {
+	parts := []string{format}
+	for _, tag := range tags {
+		parts = append(parts, fmt.Sprintf("%s\x00%s\x00%d", tag.Slug, tag.Name, tag.Count))
+	}
+	return validator{
+		ETag: h.etag(parts...),
+	}
+}
+
+// articleVersion identifies the stored version of an article by its content
+// hash, and the time it was indexed, which changes when the metadata does.
+func articleVersion(article *model.Article) string {
+	version := article.ID + "@" + article.ContentHash
+	if article.UpdatedAt != nil {
+		version += "@" + article.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return version
+}
+
+// latest returns the later of t and other, ignoring a nil other
+func latest(t time.Time, other *time.Time) time.Time {
+	if other != nil && other.After(t) {
+		return *other
+	}
+	return t
+}
+
+// notModified sets the ETag and Last-Modified headers, and replies with
+// 304 Not Modified when the If-None-Match or If-Modified-Since conditions
+// of the request match. It returns true if the response has been written.
+// Headers like Cache-Control and Vary should be set before calling it.
+func notModified(w http.ResponseWriter, r *http.Request, v validator) bool {
+	w.Header().Set("ETag", v.ETag)
+	if !v.Modified.IsZero() {
+		w.Header().Set("Last-Modified", v.Modified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatch(match, v.ETag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || v.Modified.IsZero() || v.Modified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatch reports whether an If-None-Match header value matches etag,
+// using the weak comparison required for If-None-Match
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package blog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	v := validator{ETag: `"abc"`, Modified: modified}
+
+	check := func(header, value string) (bool, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		return notModified(w, r, v), w
+	}
+
+	written, w := check("", "")
+	require.False(t, written)
+	require.Equal(t, `"abc"`, w.Header().Get("ETag"))
+	require.Equal(t, "Mon, 15 Jan 2024 10:00:00 GMT", w.Header().Get("Last-Modified"))
+
+	written, w = check("If-None-Match", `"xyz", "abc"`)
+	require.True(t, written)
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	for value, want := range map[string]bool{
+		`"abc"`:   true,
+		`W/"abc"`: true,
+		`*`:       true,
+		`"xyz"`:   false,
+	} {
+		written, _ := check("If-None-Match", value)
+		require.Equal(t, want, written, value)
+	}
+
+	for since, want := range map[time.Time]bool{
+		modified:                 true,
+		modified.Add(time.Hour):  true,
+		modified.Add(-time.Hour): false,
+	} {
+		written, _ := check("If-Modified-Since", since.Format(http.TimeFormat))
+		require.Equal(t, want, written, since)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	path := filepath.Join(dataDir, "hello.md")
+	stamp := time.Now().Add(-time.Hour)
+	writeArticle(t, path, "Hello", stamp)
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	h := &Handlers{repository: m.repository}
+
+	router := chi.NewRouter()
+	router.Get("/api/blog/articles", h.ListArticlesJSON)
+	router.Get("/api/blog/articles/{slug}", h.GetArticleJSON)
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, path := range []string{"/api/blog/articles", "/api/blog/articles/hello"} {
+		t.Run(path, func(t *testing.T) {
+			first := get(path, nil)
+			require.Equal(t, http.StatusOK, first.Code)
+			etag := first.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			second := get(path, http.Header{"If-None-Match": {etag}})
+			require.Equal(t, http.StatusNotModified, second.Code)
+			require.Empty(t, second.Body.String())
+			require.Equal(t, etag, second.Header().Get("ETag"))
+		})
+	}
+
+	t.Run("modification time", func(t *testing.T) {
+		first := get("/api/blog/articles/hello", nil)
+		require.NotEmpty(t, first.Header().Get("Last-Modified"))
+
+		w := get("/api/blog/articles/hello", http.Header{"If-Modified-Since": {first.Header().Get("Last-Modified")}})
+		require.Equal(t, http.StatusNotModified, w.Code)
+
+		// Lists don't change their modification time when an article is removed
+		require.Empty(t, get("/api/blog/articles", nil).Header().Get("Last-Modified"))
+	})
+
+	t.Run("modified article", func(t *testing.T) {
+		etag := get("/api/blog/articles/hello", nil).Header().Get("ETag")
+
+		writeArticle(t, path, "Hello again", stamp.Add(time.Minute))
+		_, _, err := m.reindex(ctx)
+		require.NoError(t, err)
+
+		w := get("/api/blog/articles/hello", http.Header{"If-None-Match": {etag}})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("deleted article", func(t *testing.T) {
+		writeArticle(t, filepath.Join(dataDir, "deleted.md"), "Deleted", stamp.Add(time.Minute))
+		_, _, err := m.reindex(ctx)
+		require.NoError(t, err)
+
+		since := time.Now().UTC().Format(http.TimeFormat)
+		require.NoError(t, os.Remove(filepath.Join(dataDir, "deleted.md")))
+		_, removed, err := m.reindex(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+
+		w := get("/api/blog/articles", http.Header{"If-Modified-Since": {since}})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotContains(t, w.Body.String(), "Deleted")
+	})
+}
@@ -0,0 +1,267 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// dataFileExts are the extensions of data files taking part in the data cascade
+var dataFileExts = []string{".json", ".yml", ".yaml"}
+
+// isDataFile returns true if the file name is a JSON or YAML data file
+func isDataFile(name string) bool {
+	ext := filepath.Ext(name)
+	for _, dataExt := range dataFileExts {
+		if ext == dataExt {
+			return true
+		}
+	}
+	return false
+}
+
+// dataFileStem returns the name of a data file without the extension and
+// the eleventy `.11tydata` suffix, e.g. `post.11tydata.json` becomes `post`.
+func dataFileStem(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.TrimSuffix(name, ".11tydata")
+}
+
+// dataCascade resolves article metadata the way eleventy's data cascade does.
+//
+// Metadata is applied in order of increasing priority:
+//
+//   - directory data files (`notes/notes.json`), from the data directory
+//     down to the article's directory,
+//   - template data files next to the article (`post.json`, `post.11tydata.json`),
+//   - the front matter of the article.
+//
+// Directory data files are named after their directory, like
+// `notes/notes.json` or `notes/notes.11tydata.json`, and template data files
+// after a markdown file in the same directory. Other data files are ignored.
+// Later layers override earlier values, except tags, which are combined.
+// Keys identifying a single article are only read from front matter.
+//
+// A cascade reads each directory and data file once, create one for every
+// scan of the data directory.
+type dataCascade struct {
+	root string
+
+	// dirs caches the data files of directories, contents the read data files
+	dirs     map[string]dataDirFiles
+	contents map[string][]byte
+}
+
+// dataDirFiles are the data files of a directory
+type dataDirFiles struct {
+	// dir holds the directory data files
+	dir []string
+
+	// template holds the template data files by the stem of their markdown file
+	template map[string][]string
+}
+
+// newDataCascade creates a data cascade for the markdown files below root
+func newDataCascade(root string) *dataCascade {
+	return &dataCascade{
+		root:     root,
+		dirs:     make(map[string]dataDirFiles),
+		contents: make(map[string][]byte),
+	}
+}
+
+// apply resolves metadata for the markdown file at path with the given front matter
+func (c *dataCascade) apply(path string, frontMatter []byte) (model.Metadata, error) {
+	var meta model.Metadata
+
+	files, err := c.files(path)
+	if err != nil {
+		return meta, err
+	}
+
+	for _, file := range files {
+		data, err := c.read(file)
+		if err != nil {
+			return meta, err
+		}
+		if err := c.merge(&meta, data); err != nil {
+			return meta, fmt.Errorf("failed to parse data file %s: %w", file, err)
+		}
+		if err := checkSharedData(data); err != nil {
+			return meta, fmt.Errorf("data file %s: %w", file, err)
+		}
+	}
+
+	if err := c.merge(&meta, frontMatter); err != nil {
+		return meta, fmt.Errorf("failed to parse YAML front matter: %w", err)
+	}
+
+	return meta, nil
+}
+
+// read returns the contents of a data file
+func (c *dataCascade) read(file string) ([]byte, error) {
+	if data, ok := c.contents[file]; ok {
+		return data, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c.contents[file] = data
+	return data, nil
+}
+
+// merge decodes a layer of YAML or JSON data over meta
+func (c *dataCascade) merge(meta *model.Metadata, data []byte) error {
+	tags := meta.Tags
+	meta.Tags = nil
+
+	if err := yaml.Unmarshal(data, meta); err != nil {
+		return err
+	}
+
+	meta.Tags = mergeTags(tags, meta.Tags)
+	return nil
+}
+
+// checkSharedData returns an error if a data file sets keys identifying a
+// single article, like `id`, `aliases` or a permalink without placeholders.
+// Those belong in front matter, and would make the articles of a directory
+// collide.
+func checkSharedData(data []byte) error {
+	var layer model.Metadata
+	if err := yaml.Unmarshal(data, &layer); err != nil {
+		return err
+	}
+
+	switch {
+	case layer.ID != "":
+		return errors.New("id identifies a single article, set it in front matter")
+	case len(layer.Aliases) > 0, len(layer.RedirectFrom) > 0:
+		return errors.New("aliases and redirect_from redirect to a single article, set them in front matter")
+	case layer.Permalink != "" && !placeholderPattern.MatchString(layer.Permalink):
+		return errors.New("permalink without placeholders is the path of a single article, set it in front matter")
+	}
+	return nil
+}
+
+// files returns the data files that apply to the markdown file at path, lowest priority first
+func (c *dataCascade) files(path string) ([]string, error) {
+	dir := filepath.Dir(path)
+
+	// Walk from the data directory down to the article directory
+	dirs := []string{dir}
+	if rel, err := filepath.Rel(c.root, dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		dirs = []string{c.root}
+		current := c.root
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			current = filepath.Join(current, part)
+			dirs = append(dirs, current)
+		}
+	}
+
+	var result []string
+	for _, d := range dirs {
+		dirData, _, err := c.dataFiles(d)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dirData...)
+	}
+
+	_, templateData, err := c.dataFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	slug := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	result = append(result, templateData[slug]...)
+
+	return result, nil
+}
+
+// dataFiles lists the directory data files in dir, and the template data
+// files in dir grouped by the stem of the markdown file they belong to.
+func (c *dataCascade) dataFiles(dir string) ([]string, map[string][]string, error) {
+	if files, ok := c.dirs[dir]; ok {
+		return files.dir, files.template, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dirName := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dirName = filepath.Base(abs)
+	}
+
+	markdown := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			markdown[strings.TrimSuffix(entry.Name(), ".md")] = true
+		}
+	}
+
+	var dirData []string
+	templateData := make(map[string][]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isDataFile(name) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		switch stem := dataFileStem(name); {
+		case stem == dirName:
+			dirData = append(dirData, path)
+		case markdown[stem]:
+			templateData[stem] = append(templateData[stem], path)
+		}
+	}
+
+	sortDataFiles(dirData)
+	for stem := range templateData {
+		sortDataFiles(templateData[stem])
+	}
+
+	c.dirs[dir] = dataDirFiles{dir: dirData, template: templateData}
+	return dirData, templateData, nil
+}
+
+// sortDataFiles sorts the data files of a stem, `post.json` applies
+// before `post.11tydata.json`
+func sortDataFiles(files []string) {
+	sort.Strings(files)
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		return strings.Contains(b, ".11tydata.") && !strings.Contains(a, ".11tydata.")
+	})
+}
+
+// mergeTags combines two tag lists, dropping duplicates
+func mergeTags(a, b model.Tags) model.Tags {
+	if len(a) == 0 {
+		return b
+	}
+
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make(model.Tags, 0, len(a)+len(b))
+	for _, tag := range append(append(model.Tags{}, a...), b...) {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package blog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestDataCascade(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, filepath.Base(root)+".json"), `{"layout": "post", "tags": "posts", "description": "root default"}`)
+	writeFile(t, filepath.Join(root, "notes", "notes.yml"), "layout: note\ntags: [notes]\n")
+	writeFile(t, filepath.Join(root, "notes", "first.md"), "")
+	writeFile(t, filepath.Join(root, "notes", "first.11tydata.json"), `{"title": "From template data", "ogImage": "/og.png"}`)
+	writeFile(t, filepath.Join(root, "notes", "first.json"), `{"title": "Overridden by 11tydata"}`)
+	writeFile(t, filepath.Join(root, "top.md"), "")
+
+	cascade := newDataCascade(root)
+
+	t.Run("directory defaults", func(t *testing.T) {
+		meta, err := cascade.apply(filepath.Join(root, "top.md"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "post", meta.Layout)
+		require.Equal(t, "root default", meta.Description)
+		require.Equal(t, model.Tags{"posts"}, meta.Tags)
+	})
+
+	t.Run("nested directories and template data", func(t *testing.T) {
+		meta, err := cascade.apply(filepath.Join(root, "notes", "first.md"), []byte("tags: [go]\ndescription: from front matter\n"))
+		require.NoError(t, err)
+		require.Equal(t, "note", meta.Layout)
+		require.Equal(t, "From template data", meta.Title)
+		require.Equal(t, "/og.png", meta.OgImage)
+		require.Equal(t, "from front matter", meta.Description)
+		require.Equal(t, model.Tags{"posts", "notes", "go"}, meta.Tags)
+	})
+
+	t.Run("template data applies to its own article only", func(t *testing.T) {
+		writeFile(t, filepath.Join(root, "notes", "second.md"), "")
+
+		meta, err := cascade.apply(filepath.Join(root, "notes", "second.md"), nil)
+		require.NoError(t, err)
+		require.Empty(t, meta.Title)
+		require.Empty(t, meta.OgImage)
+	})
+
+	t.Run("unrelated data files", func(t *testing.T) {
+		// Only data files named after the directory are directory data
+		writeFile(t, filepath.Join(root, "other", "redirects.json"), `["/a/", "/b/"]`)
+		writeFile(t, filepath.Join(root, "other", "settings.yml"), "title: Leaked\n")
+		writeFile(t, filepath.Join(root, "other", "post.md"), "")
+
+		meta, err := cascade.apply(filepath.Join(root, "other", "post.md"), nil)
+		require.NoError(t, err)
+		require.Empty(t, meta.Title)
+		require.Equal(t, "post", meta.Layout)
+	})
+
+	t.Run("article identity", func(t *testing.T) {
+		writeFile(t, filepath.Join(root, "shared", "post.md"), "")
+
+		for name, content := range map[string]string{
+			"shared.json":          `{"id": "same"}`,
+			"shared.yml":           "aliases: [/old/]\n",
+			"post.json":            `{"redirect_from": "/old/"}`,
+			"shared.11tydata.json": `{"permalink": "/same/"}`,
+		} {
+			path := filepath.Join(root, "shared", name)
+			writeFile(t, path, content)
+
+			// A cascade reads each directory once
+			_, err := newDataCascade(root).apply(filepath.Join(root, "shared", "post.md"), nil)
+			require.ErrorContains(t, err, "data file "+path, name)
+			require.ErrorContains(t, err, "in front matter", name)
+			require.NoError(t, os.Remove(path))
+		}
+
+		// Permalinks with placeholders differ between articles
+		writeFile(t, filepath.Join(root, "shared", "shared.json"), `{"permalink": "/shared/{{slug}}/"}`)
+		meta, err := newDataCascade(root).apply(filepath.Join(root, "shared", "post.md"), []byte("id: own\n"))
+		require.NoError(t, err)
+		require.Equal(t, "/shared/{{slug}}/", meta.Permalink)
+		require.Equal(t, "own", meta.ID)
+	})
+
+	t.Run("reads directories once", func(t *testing.T) {
+		cascade := newDataCascade(root)
+		for _, name := range []string{"first.md", "second.md"} {
+			_, err := cascade.apply(filepath.Join(root, "notes", name), nil)
+			require.NoError(t, err)
+		}
+		require.Len(t, cascade.dirs, 2, "the data directory and notes")
+		require.Len(t, cascade.contents, 4)
+	})
+
+	t.Run("invalid data file", func(t *testing.T) {
+		writeFile(t, filepath.Join(root, "broken", "broken.json"), `{"tags": {"a": "b"}}`)
+		writeFile(t, filepath.Join(root, "broken", "post.md"), "")
+
+		_, err := cascade.apply(filepath.Join(root, "broken", "post.md"), nil)
+		require.ErrorContains(t, err, "broken.json")
+	})
+}
+
+func TestModuleReindex_DataFiles(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	stamp := time.Now().Add(-time.Hour)
+
+	dataFile := filepath.Join(dataDir, filepath.Base(dataDir)+".json")
+	writeFile(t, dataFile, `{"tags": "posts"}`)
+	writeArticle(t, filepath.Join(dataDir, "first.md"), "First", stamp)
+	writeArticle(t, filepath.Join(dataDir, "second.md"), "Second", stamp)
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	articles, err := m.repository.GetArticlesByTag(ctx, "posts", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, articles, 2)
+
+	writeFile(t, dataFile, `{"tags": "archive"}`)
+
+	updated, removed, err := m.reindex(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, updated)
+	require.Equal(t, 0, removed)
+
+	articles, err = m.repository.GetArticlesByTag(ctx, "archive", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, articles, 2)
+}
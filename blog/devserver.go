@@ -0,0 +1,308 @@
+package blog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadPath is the Server-Sent Events endpoint of the dev server
+const reloadPath = "/_reload"
+
+// reloadScript reloads the page when the dev server sends a reload event
+const reloadScript = `<script>new EventSource("` + reloadPath + `").addEventListener("reload", () => location.reload());</script>`
+
+// devShutdownTimeout limits how long the dev server waits for open requests on shutdown
+const devShutdownTimeout = 5 * time.Second
+
+// Serve generates the site into the output directory and serves it on addr
+// for development. Changes to the data directory, theme/ and config/ reindex
+// the articles and regenerate the site incrementally, and open pages reload
+// through Server-Sent Events. The reload script is added to the pages as
+// they are served, the generated files don't include it. Build errors are
+// logged, so the server keeps running while they are fixed. Serve returns
+// when ctx is done.
+func (g *Generator) Serve(ctx context.Context, addr string) error {
+	g.SetIncremental(true)
+	if err := g.Generate(ctx); err != nil {
+		log.Printf("[serve] build failed: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	reloads := newReloads()
+	server := &http.Server{
+		Handler: newDevHandler(g.outputDir, reloads),
+		// Request contexts end with ctx, closing the event streams on shutdown
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go g.watchSources(ctx, func() {
+		changed, err := g.rebuild(ctx)
+		if err != nil {
+			log.Printf("[serve] build failed: %v", err)
+			return
+		}
+		if changed {
+			reloads.Broadcast()
+		}
+	})
+
+	fmt.Printf("Serving %s on http://%s/\n", g.outputDir, listener.Addr())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), devShutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// rebuild reindexes the changed articles and regenerates the site,
+// returning true if any output file was added, updated or removed
+func (g *Generator) rebuild(ctx context.Context) (bool, error) {
+	g.module.theme.Refresh()
+	if _, _, err := g.module.reindex(ctx); err != nil {
+		return false, err
+	}
+	if err := g.Generate(ctx); err != nil {
+		return false, err
+	}
+	return len(g.changes.Added)+len(g.changes.Updated)+len(g.changes.Removed) > 0, nil
+}
+
+// watchSources calls rebuild when files in the data directory, theme/ or
+// config/ change, until ctx is done. Directories that don't exist are
+// skipped. It falls back to polling if file notifications are unavailable.
+func (g *Generator) watchSources(ctx context.Context, rebuild func()) {
+	var dirs []string
+	for _, dir := range []string{g.module.dataDir, themeDir, "config"} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[serve] file notifications unavailable, polling: %v", err)
+		pollSources(ctx, rebuild)
+		return
+	}
+	defer watcher.Close()
+
+	addWatches := func() error {
+		for _, dir := range dirs {
+			if err := addWatchTree(watcher, dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := addWatches(); err != nil {
+		log.Printf("[serve] can't watch %s, polling instead: %v", strings.Join(dirs, ", "), err)
+		pollSources(ctx, rebuild)
+		return
+	}
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			debounce.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[serve] watch error: %v", err)
+		case <-debounce.C:
+			rebuild()
+
+			// Pick up newly created subdirectories
+			if err := addWatches(); err != nil {
+				log.Printf("[serve] watch error: %v", err)
+			}
+		}
+	}
+}
+
+// pollSources calls rebuild on a fixed interval until ctx is done. The
+// incremental build only renders files whose inputs changed.
+func pollSources(ctx context.Context, rebuild func()) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rebuild()
+		}
+	}
+}
+
+// devHandler serves the output directory like a static host, adding the
+// reload script to HTML pages and serving the reload events
+type devHandler struct {
+	root    string
+	files   http.Handler
+	reloads *reloads
+}
+
+// newDevHandler creates a devHandler serving root
+func newDevHandler(root string, reloads *reloads) *devHandler {
+	return &devHandler{
+		root:    root,
+		files:   http.FileServer(http.Dir(root)),
+		reloads: reloads,
+	}
+}
+
+// ServeHTTP serves the reload events, pages with the reload script, and
+// other files as they are. Missing files get the 404 page of the site.
+func (h *devHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == reloadPath {
+		h.reloads.ServeHTTP(w, r)
+		return
+	}
+
+	// Rebuilt files must not be served from the browser cache
+	w.Header().Set("Cache-Control", "no-store")
+
+	name := filepath.Join(h.root, outputFile(path.Clean("/"+r.URL.Path)))
+
+	info, err := os.Stat(name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		h.servePage(w, filepath.Join(h.root, "404.html"), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case !info.IsDir() && filepath.Ext(name) == ".html":
+		h.servePage(w, name, http.StatusOK)
+	default:
+		h.files.ServeHTTP(w, r)
+	}
+}
+
+// servePage writes the page with the reload script. If the page doesn't
+// exist, a plain status message is written instead.
+func (h *devHandler) servePage(w http.ResponseWriter, name string, status int) {
+	page, err := os.ReadFile(name)
+	if err != nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(injectReload(page))
+}
+
+// injectReload adds the reload script to the end of the body of a page
+func injectReload(page []byte) []byte {
+	i := bytes.LastIndex(page, []byte("</body>"))
+	if i < 0 {
+		return append(page, reloadScript...)
+	}
+
+	result := make([]byte, 0, len(page)+len(reloadScript))
+	result = append(result, page[:i]...)
+	result = append(result, reloadScript...)
+	return append(result, page[i:]...)
+}
+
+// reloads sends reload events to the open pages
+type reloads struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+// newReloads creates reloads without clients
+func newReloads() *reloads {
+	return &reloads{
+		clients: make(map[chan struct{}]struct{}),
+	}
+}
+
+// Broadcast sends a reload event to every open page
+func (r *reloads) Broadcast() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for client := range r.clients {
+		// A client with a pending reload doesn't need another one
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams reload events to a page until the request ends
+func (r *reloads) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+	r.mu.Lock()
+	r.clients[client] = struct{}{}
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.clients, client)
+		r.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package blog
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevHandler(t *testing.T) {
+	root := t.TempDir()
+	page := "<html><body><h1>About</h1></body></html>"
+	writeFile(t, filepath.Join(root, "about", "index.html"), page)
+	writeFile(t, filepath.Join(root, "404.html"), "<html><body><h1>Not Found</h1></body></html>")
+	writeFile(t, filepath.Join(root, "assets", "site.css"), "body { margin: 0 }")
+
+	handler := newDevHandler(root, newReloads())
+	get := func(urlPath string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, urlPath, nil))
+		return w
+	}
+
+	for _, urlPath := range []string{"/about/", "/about", "/about/index.html"} {
+		w := get(urlPath)
+		require.Equal(t, http.StatusOK, w.Code, urlPath)
+		require.Equal(t, "<html><body><h1>About</h1>"+reloadScript+"</body></html>", w.Body.String(), urlPath)
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	}
+
+	stored, err := os.ReadFile(filepath.Join(root, "about", "index.html"))
+	require.NoError(t, err)
+	require.Equal(t, page, string(stored), "generated files don't include the reload script")
+
+	w := get("/assets/site.css")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "body { margin: 0 }", w.Body.String())
+
+	w = get("/missing/")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), "<h1>Not Found</h1>"+reloadScript)
+
+	w = get("/assets/")
+	require.Equal(t, http.StatusNotFound, w.Code, "directories aren't listed")
+}
+
+func TestReloads(t *testing.T) {
+	reloads := newReloads()
+	server := httptest.NewServer(newDevHandler(t.TempDir(), reloads))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+reloadPath, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := events.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	require.Equal(t, ": connected\n", readEvent())
+
+	reloads.Broadcast()
+	require.Equal(t, "event: reload\ndata: {}\n", readEvent())
+
+	cancel()
+	_, err = io.ReadAll(resp.Body)
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// mediaProblem is the media type of problem details (RFC 9457)
+const mediaProblem = "application/problem+json"
+
+// problem describes an error response of the API
+type problem struct {
+	// Type identifies the problem type, `about:blank` for plain HTTP errors
+	Type string `json:"type"`
+
+	// Title is the status text
+	Title string `json:"title"`
+
+	// Status is the HTTP status code
+	Status int `json:"status"`
+
+	// Detail explains the problem, if it's safe to show
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the request path
+	Instance string `json:"instance"`
+}
+
+// isAPI returns true if the request is for the JSON API
+func isAPI(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// badRequest replies with 400 Bad Request, explaining the invalid input with err
+func (h *Handlers) badRequest(w http.ResponseWriter, r *http.Request, err error) {
+	h.httpError(w, r, http.StatusBadRequest, err.Error())
+}
+
+// notFound replies with 404 Not Found
+func (h *Handlers) notFound(w http.ResponseWriter, r *http.Request, detail string) {
+	h.httpError(w, r, http.StatusNotFound, detail)
+}
+
+// serverError logs err and replies with 500 Internal Server Error,
+// without exposing the error to the client
+func (h *Handlers) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("[blog] %s %s: %v", r.Method, r.URL.Path, err)
+	h.httpError(w, r, http.StatusInternalServerError, "")
+}
+
+// httpError replies with an error status. API requests get problem
+// details as JSON, other requests get the error page of the theme.
+// The detail is shown to the client and must not leak internals.
+func (h *Handlers) httpError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	header := w.Header()
+	header.Del("ETag")
+	header.Del("Last-Modified")
+	header.Set("Cache-Control", "no-store")
+
+	if isAPI(r) {
+		header.Set("Content-Type", mediaProblem)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   detail,
+			Instance: r.URL.Path,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if h.views != nil {
+		if err := h.views.Error(r.Context(), &buf, h.views.ErrorFromStatus(status, detail)); err != nil {
+			log.Printf("[blog] can't render error page: %v", err)
+			buf.Reset()
+		}
+	}
+	if buf.Len() == 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// writeRendered renders the response into a buffer, so a failed render
+// replies with an error page instead of a partial response
+func (h *Handlers) writeRendered(w http.ResponseWriter, r *http.Request, contentType string, render func(io.Writer) error) {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		h.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
+
+// writeJSON encodes value as the JSON response
+func (h *Handlers) writeJSON(w http.ResponseWriter, r *http.Request, value any) {
+	h.writeRendered(w, r, "application/json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(value)
+	})
+}
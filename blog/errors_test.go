@@ -0,0 +1,81 @@
+package blog
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorResponses(t *testing.T) {
+	m := newTestModule(t, t.TempDir())
+	h := &Handlers{repository: m.repository}
+
+	router := chi.NewRouter()
+	router.Get("/api/blog/articles", h.ListArticlesJSON)
+	router.Get("/api/blog/articles/{slug}", h.GetArticleJSON)
+	router.Get("/blog/{slug}/", h.GetArticleHTML)
+	router.NotFound(h.NotFound)
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) problem {
+		t.Helper()
+		require.Equal(t, mediaProblem, w.Header().Get("Content-Type"))
+
+		var result problem
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("API not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/blog/articles/missing", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		result := decode(t, w)
+		require.Equal(t, problem{
+			Type:     "about:blank",
+			Title:    "Not Found",
+			Status:   http.StatusNotFound,
+			Detail:   "article not found",
+			Instance: "/api/blog/articles/missing",
+		}, result)
+	})
+
+	t.Run("API unknown route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/blog/unknown", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, http.StatusNotFound, decode(t, w).Status)
+	})
+
+	t.Run("API bad request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/blog/articles?pageSize=1000", nil))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, decode(t, w).Detail, "pageSize")
+	})
+
+	t.Run("server errors are not exposed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.serverError(w, httptest.NewRequest(http.MethodGet, "/api/blog/articles", nil), errors.New("database is locked"))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Empty(t, decode(t, w).Detail)
+
+		w = httptest.NewRecorder()
+		h.serverError(w, httptest.NewRequest(http.MethodGet, "/blog/", nil), errors.New("database is locked"))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.NotContains(t, w.Body.String(), "database")
+	})
+
+	t.Run("HTML not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blog/missing/", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.NotContains(t, w.Header().Get("Content-Type"), "json")
+		require.NotContains(t, w.Body.String(), "sql")
+	})
+}
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"html/template"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
-	"github.com/titpetric/platform-example/blog/markdown"
+	"github.com/titpetric/platform-example/blog/model"
+	"github.com/titpetric/platform-example/blog/storage"
 	"github.com/titpetric/platform-example/blog/view"
 )
 
@@ -17,6 +22,31 @@ import (
 type Generator struct {
 	module    *Module
 	outputDir string
+
+	// redirectsFile writes the redirects to a `_redirects` file for static hosts
+	redirectsFile bool
+
+	// incremental skips rendering files whose inputs didn't change since the previous run
+	incremental bool
+
+	// jobs is the number of files rendered at the same time
+	jobs int
+
+	// workers render files while Generate runs
+	workers *workers
+
+	// mu guards current and changes, which workers update
+	mu sync.Mutex
+
+	// inputs are the hashed inputs of this run
+	inputs *buildInputs
+
+	// previous and current are the build manifests of the previous run and this run
+	previous *manifest
+	current  *manifest
+
+	// changes lists the files this run changed
+	changes buildChanges
 }
 
 // NewGenerator creates a new Generator instance
@@ -24,26 +54,78 @@ func NewGenerator(m *Module, outputDir string) *Generator {
 	return &Generator{
 		module:    m,
 		outputDir: outputDir,
+		jobs:      1,
+		inputs:    &buildInputs{},
+		previous:  newManifest(),
+		current:   newManifest(),
 	}
 }
 
-// Generate generates all static HTML files
+// SetRedirectsFile enables writing the redirects to a `_redirects` file,
+// as used by static hosts like Netlify and Cloudflare Pages
+func (g *Generator) SetRedirectsFile(enabled bool) {
+	g.redirectsFile = enabled
+}
+
+// SetIncremental enables incremental generation. Files are only rendered
+// if their inputs changed since the run that wrote the build manifest.
+func (g *Generator) SetIncremental(enabled bool) {
+	g.incremental = enabled
+}
+
+// SetConcurrency sets the number of files rendered at the same time
+func (g *Generator) SetConcurrency(jobs int) {
+	g.jobs = max(jobs, 1)
+}
+
+// Generate generates all static HTML files. Files generated by the
+// previous run whose sources were deleted are removed. Files are
+// rendered concurrently, and the errors of all failed files are returned.
 func (g *Generator) Generate(ctx context.Context) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(g.outputDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	manifestPath := filepath.Join(g.outputDir, manifestFile)
+	previous, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	g.previous, g.current, g.changes = previous, newManifest(), buildChanges{}
+
+	// Create handlers for rendering
+	h, err := newHandlers(g.module.repository, g.module.theme)
+	if err != nil {
+		return fmt.Errorf("failed to create handlers: %w", err)
+	}
+
+	// Fail before writing anything if two pages or articles share a path
+	if err := g.checkPermalinks(ctx, h); err != nil {
+		return err
+	}
+
+	g.inputs, err = g.readInputs(ctx, h)
+	if err != nil {
+		return fmt.Errorf("failed to hash inputs: %w", err)
+	}
+
+	g.workers = newWorkers(g.jobs)
+	defer func() {
+		g.workers.Wait()
+		g.workers = nil
+	}()
+
 	// Copy static assets
 	fmt.Println("Copying assets...")
 	if err := g.copyAssets(); err != nil {
 		return fmt.Errorf("failed to copy assets: %w", err)
 	}
 
-	// Create handlers for rendering
-	h, err := NewHandlers(g.module.repository, g.module.themeFS)
-	if err != nil {
-		return fmt.Errorf("failed to create handlers: %w", err)
+	// Generate stylesheets of highlighted code
+	fmt.Println("Generating stylesheets...")
+	if err := g.generateStylesheets(h); err != nil {
+		return fmt.Errorf("failed to generate stylesheets: %w", err)
 	}
 
 	// Generate index page
@@ -58,30 +140,21 @@ func (g *Generator) Generate(ctx context.Context) error {
 		return fmt.Errorf("failed to generate static pages: %w", err)
 	}
 
-	// Generate individual article pages
-	articles, err := g.module.repository.GetArticles(ctx, 0, 9999)
+	// Generate paginated blog list
+	fmt.Println("Generating blog pages...")
+	if err := g.generateBlogPages(ctx, h); err != nil {
+		return fmt.Errorf("failed to generate blog pages: %w", err)
+	}
+
+	// Generate individual article pages, unlisted articles are reachable by URL
+	articles, err := g.module.repository.WithUnlisted().GetArticles(ctx, 0, 9999)
 	if err != nil {
 		return fmt.Errorf("failed to fetch articles: %w", err)
 	}
 
-	mdRenderer := markdown.NewRenderer()
+	fmt.Printf("Generating %d articles...\n", len(articles))
 	for _, modelArticle := range articles {
-		fmt.Printf("Generating blog/%s/index.html...\n", modelArticle.Slug)
-
-		content, err := os.ReadFile(modelArticle.Filename)
-		if err != nil {
-			return err
-		}
-
-		// Convert markdown to HTML
-		contentWithoutFrontMatter := view.StripFrontMatter(content)
-
-		htmlContent := mdRenderer.Render(contentWithoutFrontMatter)
-
-		// Create PostData
-		postData := h.views.PostFromArticle(&modelArticle, string(htmlContent))
-
-		if err := g.generateArticlePage(ctx, h, postData); err != nil {
+		if err := g.generateArticlePage(ctx, h, &modelArticle); err != nil {
 			return fmt.Errorf("failed to generate article page for %s: %w", modelArticle.Slug, err)
 		}
 	}
@@ -92,237 +165,328 @@ func (g *Generator) Generate(ctx context.Context) error {
 		return fmt.Errorf("failed to generate feed: %w", err)
 	}
 
-	fmt.Printf("✓ Generated %d articles\n", len(articles))
-	return nil
-}
-
-// generateIndexPage generates the index.html file
-func (g *Generator) generateIndexPage(ctx context.Context, h *Handlers) error {
-	articles, err := h.repository.GetArticles(ctx, 0, 5)
-	if err != nil {
-		return err
+	// Generate sitemap.xml
+	fmt.Println("Generating sitemap.xml...")
+	if err := g.generateSitemap(ctx, h); err != nil {
+		return fmt.Errorf("failed to generate sitemap: %w", err)
 	}
 
-	indexData := h.views.IndexFromArticles(articles)
+	// Generate tag pages and feeds
+	fmt.Println("Generating tag pages...")
+	if err := g.generateTagPages(ctx, h); err != nil {
+		return fmt.Errorf("failed to generate tag pages: %w", err)
+	}
 
-	var buf bytes.Buffer
-	if err := h.views.Index(ctx, &buf, indexData); err != nil {
-		return err
+	if err := g.workers.Wait(); err != nil {
+		return fmt.Errorf("failed to generate files:\n%w", err)
 	}
 
-	indexPath := filepath.Join(g.outputDir, "index.html")
-	return os.WriteFile(indexPath, buf.Bytes(), 0o644)
-}
+	// Generate redirect stubs last, so they never replace a page
+	fmt.Println("Generating redirects...")
+	if err := g.generateRedirects(ctx, h); err != nil {
+		return fmt.Errorf("failed to generate redirects: %w", err)
+	}
+	if err := g.workers.Wait(); err != nil {
+		return fmt.Errorf("failed to generate redirects:\n%w", err)
+	}
 
-// generateStaticPages generates all .vuego pages from theme/pages directory recursively
-func (g *Generator) generateStaticPages(ctx context.Context, h *Handlers) error {
-	return g.walkPages(ctx, h, "pages", "")
-}
+	// Remove files whose sources were deleted
+	if err := g.removeStale(); err != nil {
+		return fmt.Errorf("failed to remove stale files: %w", err)
+	}
 
-func (g *Generator) walkPages(ctx context.Context, h *Handlers, dirPath string, relPath string) error {
-	entries, err := fs.ReadDir(g.module.themeFS, dirPath)
-	if err != nil {
-		return fmt.Errorf("failed to read pages directory %s: %w", dirPath, err)
+	if err := g.current.write(manifestPath); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
-	for _, entry := range entries {
-		entryPath := filepath.Join(dirPath, entry.Name())
-		entryRelPath := filepath.Join(relPath, entry.Name())
+	g.changes.Print()
+	fmt.Printf("✓ Generated %d articles: %s\n", len(articles), g.changes)
+	return nil
+}
 
-		if entry.IsDir() {
-			// Recursively walk subdirectories
-			if err := g.walkPages(ctx, h, entryPath, entryRelPath); err != nil {
-				return err
-			}
-			continue
+// generateIndexPage generates the index.html file
+func (g *Generator) generateIndexPage(ctx context.Context, h *Handlers) error {
+	return g.write("index.html", g.inputs.Content, func(w io.Writer) error {
+		articles, err := h.repository.GetArticles(ctx, 0, 5)
+		if err != nil {
+			return err
 		}
 
-		if !strings.HasSuffix(entry.Name(), ".vuego") {
-			continue
-		}
+		return h.views.Index(ctx, w, h.views.IndexFromArticles(articles))
+	})
+}
 
-		// Skip index.vuego in root pages (handled separately)
-		if entry.Name() == "index.vuego" && relPath == "" {
-			continue
+// generateStaticPages generates the theme pages, using the routing table of the server
+func (g *Generator) generateStaticPages(ctx context.Context, h *Handlers) error {
+	pages, err := themePages(g.module.themeFS)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		err := g.write(filepath.ToSlash(page.Output()), g.inputs.Site, func(w io.Writer) error {
+			return h.renderPage(ctx, w, page)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to render page %s: %w", page.Template, err)
 		}
+	}
 
-		pageName := strings.TrimSuffix(entry.Name(), ".vuego")
-		templatePath := filepath.Join("pages", strings.ReplaceAll(entryRelPath, string(filepath.Separator), "/"))
+	return nil
+}
 
-		// Determine output path and prepare data
-		var outputPath string
-		var templateData map[string]interface{}
+// generateBlogPages generates the paginated article list, blog/index.html
+// for the first page and blog/page/{n}/index.html for the rest
+func (g *Generator) generateBlogPages(ctx context.Context, h *Handlers) error {
+	page := pagination{
+		Page:     1,
+		PageSize: defaultPageSize,
+	}
+	pageURL := blogPageURL(nil)
 
-		if entry.Name() == "index.vuego" {
-			// index.vuego in subdirectories becomes subdir/index.html
-			parentDir := strings.TrimSuffix(entryRelPath, string(filepath.Separator)+"index.vuego")
-			outputDir := filepath.Join(g.outputDir, parentDir)
-			if err := os.MkdirAll(outputDir, 0o755); err != nil {
-				return err
-			}
-			outputPath = filepath.Join(outputDir, "index.html")
-
-			// Special handling for blog/index.vuego
-			if parentDir == "blog" || parentDir == "blog"+string(filepath.Separator) {
-				articles, err := h.repository.GetArticles(ctx, 0, 9999)
-				if err != nil {
-					return fmt.Errorf("failed to fetch articles for blog page: %w", err)
-				}
-				templateData = map[string]interface{}{
-					"articles": articles,
-					"total":    len(articles),
-				}
-			} else {
-				templateData = map[string]interface{}{}
-			}
-		} else {
-			// Regular pages become page-name.html
-			outputPath = filepath.Join(g.outputDir, pageName+".html")
-
-			// Special handling for blog.vuego
-			if pageName == "blog" {
-				articles, err := h.repository.GetArticles(ctx, 0, 9999)
-				if err != nil {
-					return fmt.Errorf("failed to fetch articles for blog page: %w", err)
-				}
-				templateData = map[string]interface{}{
-					"articles": articles,
-					"total":    len(articles),
-				}
-			} else {
-				templateData = map[string]interface{}{}
-			}
+	for {
+		articles, err := h.articlePage(ctx, storage.ArticleFilter{}, &page)
+		if err != nil {
+			return err
 		}
 
-		// Render the page
-		var buf bytes.Buffer
-		if err := h.views.Render(ctx, &buf, templatePath, templateData); err != nil {
-			return fmt.Errorf("failed to render page %s: %w", templatePath, err)
+		// The list is built before the page is rendered by a worker, as page changes
+		list := page.List(articles, pageURL)
+		name := path.Join(strings.Trim(pageURL(page.Page), "/"), "index.html")
+		err = g.write(name, g.inputs.Content, func(w io.Writer) error {
+			return h.views.Blog(ctx, w, h.views.IndexFromList(list))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to render blog page %d: %w", page.Page, err)
 		}
 
-		// Write the file
-		if err := os.WriteFile(outputPath, buf.Bytes(), 0o644); err != nil {
-			return fmt.Errorf("failed to write page %s: %w", outputPath, err)
+		if page.Page >= page.Pages() {
+			return nil
 		}
-
-		fmt.Printf("Generated %s\n", outputPath)
+		page.Page++
 	}
+}
 
-	return nil
+// generateArticlePage generates an individual article page at its permalink
+func (g *Generator) generateArticlePage(ctx context.Context, h *Handlers, article *model.Article) error {
+	inputs := inputHash(g.inputs.Site, g.inputs.Articles[article.ID])
+	return g.write(filepath.ToSlash(outputFile(article.URL)), inputs, func(w io.Writer) error {
+		return h.views.Post(ctx, w, h.views.PostFromArticle(article, article.BodyHTML))
+	})
 }
 
-// generateArticlePage generates an individual article page
-func (g *Generator) generateArticlePage(ctx context.Context, h *Handlers, postData *view.PostData) error {
-	var buf bytes.Buffer
-	if err := h.views.Post(ctx, &buf, postData); err != nil {
+// checkPermalinks returns an error if two theme pages or articles are
+// served at the same path, one uses a path generated for a route, or two
+// articles share a slug
+func (g *Generator) checkPermalinks(ctx context.Context, h *Handlers) error {
+	pages, err := themePages(g.module.themeFS)
+	if err != nil {
 		return err
 	}
 
-	articleDir := filepath.Join(g.outputDir, "blog", postData.Slug)
-	if err := os.MkdirAll(articleDir, 0o755); err != nil {
+	tags, err := h.repository.GetTags(ctx)
+	if err != nil {
 		return err
 	}
 
-	articlePath := filepath.Join(articleDir, "index.html")
-	return os.WriteFile(articlePath, buf.Bytes(), 0o644)
-}
-
-// generateFeed generates the feed.xml file
-func (g *Generator) generateFeed(ctx context.Context, h *Handlers) error {
-	articles, err := h.repository.GetArticles(ctx, 0, 20)
+	articles, err := h.repository.WithUnlisted().GetArticles(ctx, 0, 9999)
 	if err != nil {
 		return err
 	}
 
-	var buf bytes.Buffer
-	if err := h.views.AtomFeed(ctx, &buf, articles); err != nil {
+	total, err := h.repository.CountFilteredArticles(ctx, storage.ArticleFilter{})
+	if err != nil {
 		return err
 	}
 
-	feedPath := filepath.Join(g.outputDir, "feed.xml")
-	return os.WriteFile(feedPath, buf.Bytes(), 0o644)
-}
-
-// copyAssets copies static assets from theme/assets (both embedded and local) to output directory
-func (g *Generator) copyAssets() error {
-	assetsDestDir := filepath.Join(g.outputDir, "assets")
-
-	// First, copy embedded theme assets using the overlay FS
-	if err := g.copyEmbeddedAssets(assetsDestDir); err != nil {
-		return err
+	// The paginated blog list and the tag pages are generated for routes
+	claims := newPermalinks()
+	list := pagination{PageSize: defaultPageSize, Total: total}
+	pageURL := blogPageURL(nil)
+	for n := 2; n <= list.Pages(); n++ {
+		claims.ClaimRoute(pageURL(n))
+	}
+	for _, tag := range tags {
+		claims.ClaimRoute(view.TagURL(tag.Slug))
+		claims.ClaimRoute(view.TagURL(tag.Slug) + "feed.xml")
 	}
 
-	// Then, copy local theme/assets if it exists (local overrides embedded)
-	if err := g.copyLocalAssets(assetsDestDir); err != nil {
-		return err
+	for _, page := range pages {
+		if err := claims.Claim(page.Path, page.Template); err != nil {
+			return err
+		}
 	}
+	// The slug is the default path of an article, which redirects to its URL
+	slugs := make(map[string]string, len(articles))
+	for _, article := range articles {
+		if other, ok := slugs[article.Slug]; ok {
+			return fmt.Errorf("%s and %s both have the slug %s", other, article.Filename, article.Slug)
+		}
+		slugs[article.Slug] = article.Filename
 
+		if err := claims.Claim(article.URL, article.Filename); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// copyEmbeddedAssets copies assets from the embedded theme filesystem
-func (g *Generator) copyEmbeddedAssets(assetsDestDir string) error {
-	return fs.WalkDir(g.module.themeFS, "assets", func(path string, d fs.DirEntry, err error) error {
+// generateSitemap generates the sitemap.xml file
+func (g *Generator) generateSitemap(ctx context.Context, h *Handlers) error {
+	return g.write("sitemap.xml", g.inputs.Content, func(w io.Writer) error {
+		pages, err := themePages(g.module.themeFS)
 		if err != nil {
 			return err
 		}
 
-		// Get relative path within assets
-		relPath, err := filepath.Rel("assets", path)
+		articles, err := h.repository.GetArticles(ctx, 0, 9999)
 		if err != nil {
 			return err
 		}
 
-		destPath := filepath.Join(assetsDestDir, relPath)
-
-		if d.IsDir() {
-			return os.MkdirAll(destPath, 0o755)
+		tags, err := h.repository.GetTags(ctx)
+		if err != nil {
+			return err
 		}
 
-		// Read from embedded FS and write to dest
-		data, err := fs.ReadFile(g.module.themeFS, path)
+		return h.views.Sitemap(ctx, w, sitemapURLs(pages, articles, tags))
+	})
+}
+
+// generateFeed generates the feed.xml file
+func (g *Generator) generateFeed(ctx context.Context, h *Handlers) error {
+	return g.write("feed.xml", g.inputs.Content, func(w io.Writer) error {
+		articles, err := h.repository.GetArticles(ctx, 0, 20)
 		if err != nil {
 			return err
 		}
 
-		return os.WriteFile(destPath, data, 0o644)
+		return h.views.AtomFeed(ctx, w, articles)
 	})
 }
 
-// copyLocalAssets copies assets from local theme/assets directory, overriding embedded assets
-func (g *Generator) copyLocalAssets(assetsDestDir string) error {
-	assetsSrcDir := filepath.Join("theme", "assets")
+// generateTagPages generates the tag index and a page and feed for each tag
+func (g *Generator) generateTagPages(ctx context.Context, h *Handlers) error {
+	tags, err := h.repository.GetTags(ctx)
+	if err != nil {
+		return err
+	}
 
-	// Check if source exists
-	if _, err := os.Stat(assetsSrcDir); os.IsNotExist(err) {
-		// Assets directory doesn't exist, skip
-		return nil
+	err = g.write("blog/tags/index.html", g.inputs.Content, func(w io.Writer) error {
+		return h.views.Tags(ctx, w, h.views.TagsFromCounts(tags))
+	})
+	if err != nil {
+		return err
 	}
 
-	// Copy the entire assets directory
-	return filepath.Walk(assetsSrcDir, func(path string, info os.FileInfo, err error) error {
+	for _, tagCount := range tags {
+		tag := tagCount.Tag
+		tagDir := path.Join("blog", "tags", tag.Slug)
+
+		err := g.write(path.Join(tagDir, "index.html"), g.inputs.Content, func(w io.Writer) error {
+			articles, err := h.repository.GetArticlesByTag(ctx, tag.Slug, 0, 9999)
+			if err != nil {
+				return err
+			}
+			return h.views.Tag(ctx, w, h.views.TagFromArticles(&tag, articles))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to render tag %s: %w", tag.Slug, err)
+		}
+
+		// The feed is limited to the most recent articles, like feed.xml
+		err = g.write(path.Join(tagDir, "feed.xml"), g.inputs.Content, func(w io.Writer) error {
+			articles, err := h.repository.GetArticlesByTag(ctx, tag.Slug, 0, 20)
+			if err != nil {
+				return err
+			}
+			return h.views.TagFeed(ctx, w, &tag, articles)
+		})
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to generate feed for tag %s: %w", tag.Slug, err)
+		}
+	}
+
+	return nil
+}
+
+// redirectTemplate is a page redirecting to the target with a meta refresh
+var redirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Redirecting to {{.}}</title>
+<link rel="canonical" href="{{.}}">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url={{.}}">
+</head>
+<body>
+<p>This page has moved to <a href="{{.}}">{{.}}</a>.</p>
+</body>
+</html>
+`))
+
+// generateRedirects writes a page redirecting to the target for every
+// redirect, and the `_redirects` file if enabled. Redirects from paths
+// with an extension are written to that file name, others to index.html
+// in the directory of the path. Generated pages and files the generator
+// didn't write are left alone.
+func (g *Generator) generateRedirects(ctx context.Context, h *Handlers) error {
+	redirects, err := h.repository.GetRedirects(ctx)
+	if err != nil {
+		return err
+	}
+
+	var list bytes.Buffer
+	for _, redirect := range redirects {
+		fmt.Fprintf(&list, "%s %s 301\n", redirect.Path, redirect.Target)
+
+		name := strings.TrimPrefix(redirect.Path, "/")
+		if path.Ext(redirect.Path) == "" {
+			name = path.Join(name, "index.html")
+		}
+		if _, ok := g.current.Outputs[name]; ok {
+			continue
+		}
+		if _, ok := g.previous.Outputs[name]; !ok {
+			if _, err := os.Stat(filepath.Join(g.outputDir, filepath.FromSlash(name))); err == nil {
+				continue
+			}
 		}
 
-		// Get relative path
-		relPath, err := filepath.Rel(assetsSrcDir, path)
+		err := g.write(name, inputHash("redirect", redirect.Target), func(w io.Writer) error {
+			return redirectTemplate.Execute(w, redirect.Target)
+		})
 		if err != nil {
 			return err
 		}
+	}
 
-		destPath := filepath.Join(assetsDestDir, relPath)
+	if !g.redirectsFile {
+		return nil
+	}
+	return g.write("_redirects", inputHash(list.String()), func(w io.Writer) error {
+		_, err := w.Write(list.Bytes())
+		return err
+	})
+}
 
-		if info.IsDir() {
-			return os.MkdirAll(destPath, 0o755)
+// copyAssets copies static assets from the theme to the output directory.
+// The theme is an overlay, so local theme/assets override embedded assets.
+func (g *Generator) copyAssets() error {
+	return fs.WalkDir(g.module.themeFS, "assets", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
 		}
 
-		// Copy file
-		data, err := os.ReadFile(path)
+		data, err := fs.ReadFile(g.module.themeFS, name)
 		if err != nil {
 			return err
 		}
 
-		return os.WriteFile(destPath, data, 0o644)
+		return g.write(name, inputHash(string(data)), func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		})
 	})
 }
@@ -0,0 +1,65 @@
+package blog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/storage"
+)
+
+func TestGenerateRemovesDeletedArticles(t *testing.T) {
+	// The views read the site configuration relative to the working directory
+	t.Chdir("appdata")
+
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	// The database persists between runs, like the one of cmd/generate
+	db, err := sqlx.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repo := storage.NewStorage(db)
+	require.NoError(t, repo.InitSchema(ctx))
+
+	generate := func() *Generator {
+		m := NewModule(dataDir)
+		m.SetRepository(repo)
+		_, err := m.ScanMarkdownFiles(ctx)
+		require.NoError(t, err)
+
+		g := NewGenerator(m, outputDir)
+		g.SetIncremental(true)
+		require.NoError(t, g.Generate(ctx))
+		return g
+	}
+
+	stamp := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	writeArticle(t, filepath.Join(dataDir, "kept.md"), "Kept", stamp)
+	writeArticle(t, filepath.Join(dataDir, "deleted.md"), "Deleted", stamp)
+	generate()
+
+	deleted := filepath.Join(outputDir, "blog", "deleted", "index.html")
+	_, err = os.Stat(deleted)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dataDir, "deleted.md")))
+	g := generate()
+
+	require.Contains(t, g.changes.Removed, "blog/deleted/index.html")
+	_, err = os.Stat(deleted)
+	require.True(t, os.IsNotExist(err), "the output of a deleted article is removed")
+	_, err = os.Stat(filepath.Join(outputDir, "blog", "kept", "index.html"))
+	require.NoError(t, err)
+
+	_, err = repo.GetArticleBySlug(ctx, "deleted")
+	require.Error(t, err, "the article of a deleted file is removed from the database")
+}
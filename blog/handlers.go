@@ -1,15 +1,18 @@
 package blog
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
 
 	chi "github.com/go-chi/chi/v5"
 
-	"github.com/titpetric/platform-example/blog/markdown"
 	"github.com/titpetric/platform-example/blog/model"
 	"github.com/titpetric/platform-example/blog/storage"
 	"github.com/titpetric/platform-example/blog/view"
@@ -19,11 +22,26 @@ import (
 type Handlers struct {
 	repository *storage.Storage
 	views      *view.Views
+
+	// theme identifies the theme version, it is part of every ETag
+	theme *themeVersion
+
+	// pages caches rendered article pages by ETag
+	pages *memoryCache[[]byte]
+
+	// routes is the routing table of theme pages
+	routes *pageRoutes
 }
 
 // NewHandlers creates a new Handlers instance with the given storage
 func NewHandlers(repo *storage.Storage, themeFS fs.FS) (*Handlers, error) {
-	views, err := view.NewViews(themeFS)
+	return newHandlers(repo, newThemeVersion(themeFS))
+}
+
+// newHandlers creates the handlers rendering the theme, sharing its version
+// with the module, which refreshes it when the theme changes
+func newHandlers(repo *storage.Storage, theme *themeVersion) (*Handlers, error) {
+	views, err := view.NewViews(theme.fs)
 	if err != nil {
 		return nil, err
 	}
@@ -31,159 +49,473 @@ func NewHandlers(repo *storage.Storage, themeFS fs.FS) (*Handlers, error) {
 	return &Handlers{
 		repository: repo,
 		views:      views,
+		theme:      theme,
+		pages:      newMemoryCache[[]byte](renderCacheSize),
+		routes:     &pageRoutes{theme: theme},
 	}, nil
 }
 
-// ListArticlesJSON returns a JSON list of all articles
+// ListArticlesJSON returns a paginated JSON list of articles.
+// The list can be filtered by `year`, `month` and `source`.
 func (h *Handlers) ListArticlesJSON(w http.ResponseWriter, r *http.Request) {
-	articles, err := h.repository.GetArticles(r.Context(), 0, 9999)
+	query := r.URL.Query()
+
+	page, err := parsePagination(query)
+	if err != nil {
+		h.badRequest(w, r, err)
+		return
+	}
+
+	filter, err := parseArticleFilter(query)
+	if err != nil {
+		h.badRequest(w, r, err)
+		return
+	}
+
+	articles, err := h.articlePage(r.Context(), filter, &page)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to fetch articles: %v", err), http.StatusInternalServerError)
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	h.listJSON(w, r, page.List(articles, queryPageURL(r.URL.Path, query)))
+}
+
+// listJSON writes a page of articles as JSON
+func (h *Handlers) listJSON(w http.ResponseWriter, r *http.Request, list *model.ArticleList) {
 	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Link", linkHeader(list.Links))
 
-	list := &model.ArticleList{
-		Articles: articles,
-		Total:    len(articles),
-		Page:     1,
-		PageSize: len(articles),
+	if notModified(w, r, h.articlesValidator(mediaJSON, list.Articles, list.Total, list.Page, list.PageSize)) {
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(list); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	h.writeJSON(w, r, list)
+}
+
+// articlePage fetches a page of articles matching filter and fills in the total
+func (h *Handlers) articlePage(ctx context.Context, filter storage.ArticleFilter, page *pagination) ([]model.Article, error) {
+	total, err := h.repository.CountFilteredArticles(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
+	page.Total = total
+
+	return h.repository.FilterArticles(ctx, filter, page.Offset(), page.PageSize)
 }
 
 // GetArticleJSON returns a single article as JSON
 func (h *Handlers) GetArticleJSON(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
+	w.Header().Add("Vary", "Accept")
+
 	article, err := h.repository.GetArticleBySlug(r.Context(), slug)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("article not found: %v", err), http.StatusNotFound)
+		if h.redirectRenamed(w, r, slug, articleAPIURL) {
+			return
+		}
+		h.notFound(w, r, "article not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	h.articleJSON(w, r, article)
+}
+
+// redirectRenamed permanently redirects a request for the previous slug of
+// a renamed article to the URL of the article returned by location. It
+// returns true if the response has been written.
+func (h *Handlers) redirectRenamed(w http.ResponseWriter, r *http.Request, slug string, location func(*model.Article) string) bool {
+	article, err := h.repository.GetArticleByPreviousSlug(r.Context(), slug)
+	if err != nil {
+		return false
+	}
+
+	target := location(article)
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
+}
+
+// articleURL returns the URL of the article page
+func articleURL(article *model.Article) string {
+	return article.URL
+}
+
+// articleAPIURL returns the URL of the article in the API
+func articleAPIURL(article *model.Article) string {
+	return "/api/blog/articles/" + article.Slug
+}
+
+// articleJSON writes a single article as JSON
+func (h *Handlers) articleJSON(w http.ResponseWriter, r *http.Request, article *model.Article) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	if notModified(w, r, h.articleValidator(mediaJSON, article)) {
+		return
+	}
+
+	h.writeJSON(w, r, article)
+}
+
+// articleMarkdown writes the markdown source of an article
+func (h *Handlers) articleMarkdown(w http.ResponseWriter, r *http.Request, article *model.Article) {
 	w.Header().Set("Cache-Control", "public, max-age=3600")
 
-	if err := json.NewEncoder(w).Encode(article); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if notModified(w, r, h.articleValidator(mediaMarkdown, article)) {
+		return
 	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+
+	io.WriteString(w, article.BodyMarkdown)
 }
 
 // SearchArticlesJSON performs full-text search on articles
 func (h *Handlers) SearchArticlesJSON(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		http.Error(w, "missing 'q' query parameter", http.StatusBadRequest)
+		h.badRequest(w, r, errors.New("missing 'q' query parameter"))
 		return
 	}
 
 	articles, err := h.repository.SearchArticles(r.Context(), query)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("search failed: %v", err), http.StatusInternalServerError)
+		h.serverError(w, r, fmt.Errorf("search failed: %w", err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "public, max-age=300")
 
+	found := make([]model.Article, 0, len(articles))
+	for _, result := range articles {
+		found = append(found, result.Article)
+	}
+	if notModified(w, r, h.articlesValidator(mediaJSON, found, query)) {
+		return
+	}
+
 	result := map[string]interface{}{
 		"articles": articles,
 		"total":    len(articles),
 		"query":    query,
 	}
 
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	h.writeJSON(w, r, result)
 }
 
 // IndexHTML returns an HTML index page listing blogs
 func (h *Handlers) IndexHTML(w http.ResponseWriter, r *http.Request) {
 	articles, err := h.repository.GetArticles(r.Context(), 0, 5)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to fetch articles: %v", err), http.StatusInternalServerError)
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Cache-Control", "public, max-age=300")
 
+	if notModified(w, r, h.articlesValidator(mediaHTML, articles, "index")) {
+		return
+	}
+
 	// Create index component to render list
 	indexData := h.views.IndexFromArticles(articles)
 
-	if err := h.views.Index(r.Context(), w, indexData); err != nil {
-		http.Error(w, fmt.Sprintf("render failed: %v", err), http.StatusInternalServerError)
-	}
+	h.writeRendered(w, r, "text/html; charset=utf-8", func(w io.Writer) error {
+		return h.views.Index(r.Context(), w, indexData)
+	})
 }
 
-// ListArticlesHTML returns an HTML list of articles
+// ListArticlesHTML returns a paginated HTML list of articles,
+// `/blog/` for the first page and `/blog/page/{page}/` for the rest.
+// Depending on the Accept header, the page is returned as JSON, or
+// the Atom feed is returned instead.
 func (h *Handlers) ListArticlesHTML(w http.ResponseWriter, r *http.Request) {
-	articles, err := h.repository.GetArticles(r.Context(), 0, 9999)
+	w.Header().Add("Vary", "Accept")
+
+	format := negotiate(r, mediaHTML, mediaJSON, mediaAtom)
+	if format == mediaAtom {
+		h.atomFeed(w, r, "application/atom+xml; charset=utf-8")
+		return
+	}
+
+	query := r.URL.Query()
+
+	page := pagination{
+		Page:     1,
+		PageSize: defaultPageSize,
+	}
+	if value := chi.URLParam(r, "page"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			h.notFound(w, r, "")
+			return
+		}
+		// The first page is served from /blog/
+		if number == 1 {
+			http.Redirect(w, r, blogPageURL(query)(1), http.StatusMovedPermanently)
+			return
+		}
+		page.Page = number
+	}
+
+	filter, err := parseArticleFilter(query)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to fetch articles: %v", err), http.StatusInternalServerError)
+		h.badRequest(w, r, err)
+		return
+	}
+
+	articles, err := h.articlePage(r.Context(), filter, &page)
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
+		return
+	}
+	if page.Page > page.Pages() {
+		h.notFound(w, r, "")
+		return
+	}
+
+	list := page.List(articles, blogPageURL(query))
+	if format == mediaJSON {
+		h.listJSON(w, r, list)
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Cache-Control", "public, max-age=300")
 
+	if notModified(w, r, h.articlesValidator(mediaHTML, articles, list.Total, list.Page, list.Links)) {
+		return
+	}
+
 	// Create blog list and render
-	blogData := h.views.IndexFromArticles(articles)
+	blogData := h.views.IndexFromList(list)
 
-	if err := h.views.Blog(r.Context(), w, blogData); err != nil {
-		http.Error(w, fmt.Sprintf("render failed: %v", err), http.StatusInternalServerError)
-	}
+	h.writeRendered(w, r, "text/html; charset=utf-8", func(w io.Writer) error {
+		return h.views.Blog(r.Context(), w, blogData)
+	})
 }
 
-// GetArticleHTML returns a single article as HTML
+// GetArticleHTML returns a single article as HTML. Depending on the
+// Accept header, the article is returned as JSON or as markdown source.
 func (h *Handlers) GetArticleHTML(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
+	w.Header().Add("Vary", "Accept")
+
 	article, err := h.repository.GetArticleBySlug(r.Context(), slug)
 	if err != nil {
-		http.NotFound(w, r)
+		if h.redirectRenamed(w, r, slug, articleURL) {
+			return
+		}
+		h.NotFound(w, r)
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=3600")
+	// An article with a permalink is served at its permalink
+	if strings.TrimSuffix(article.URL, "/") != strings.TrimSuffix(r.URL.Path, "/") {
+		target := article.URL
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	h.articleHTML(w, r, article)
+}
 
-	content, err := os.ReadFile(article.Filename)
+// serveArticle serves the article with a permalink at the request path.
+// It returns false if there's no article at the path.
+func (h *Handlers) serveArticle(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	urlPath := r.URL.Path
+	article, err := h.repository.GetArticleByURL(r.Context(), urlPath)
+	if err != nil && !strings.HasSuffix(urlPath, "/") {
+		article, err = h.repository.GetArticleByURL(r.Context(), urlPath+"/")
+	}
 	if err != nil {
-		http.NotFound(w, r)
+		return false
+	}
+
+	w.Header().Add("Vary", "Accept")
+	h.articleHTML(w, r, article)
+	return true
+}
+
+// articleHTML writes an article as HTML, or as JSON or markdown source
+// depending on the Accept header
+func (h *Handlers) articleHTML(w http.ResponseWriter, r *http.Request, article *model.Article) {
+	switch negotiate(r, mediaHTML, mediaJSON, mediaMarkdown) {
+	case mediaJSON:
+		h.articleJSON(w, r, article)
+		return
+	case mediaMarkdown:
+		h.articleMarkdown(w, r, article)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	// Skip rendering the article if the client has it
+	v := h.articleValidator(mediaHTML, article)
+	if notModified(w, r, v) {
 		return
 	}
 
-	contentWithoutFrontMatter := view.StripFrontMatter(content)
-	mdRenderer := markdown.NewRenderer()
-	htmlContent := mdRenderer.Render(contentWithoutFrontMatter)
+	// The ETag covers the article and the theme, so it identifies the rendered page
+	page, ok := h.pages.Get(v.ETag)
+	if !ok {
+		// Create PostData and render
+		postData := h.views.PostFromArticle(article, article.BodyHTML)
 
-	// Create PostData and render
-	postData := h.views.PostFromArticle(article, string(htmlContent))
+		var buf bytes.Buffer
+		if err := h.views.Post(r.Context(), &buf, postData); err != nil {
+			h.serverError(w, r, fmt.Errorf("render failed: %w", err))
+			return
+		}
 
-	if err := h.views.Post(r.Context(), w, postData); err != nil {
-		http.Error(w, fmt.Sprintf("render failed: %v", err), http.StatusInternalServerError)
+		page = buf.Bytes()
+		h.pages.Set(v.ETag, page)
 	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
 }
 
 // GetAtomFeed returns an Atom XML feed of all articles
 func (h *Handlers) GetAtomFeed(w http.ResponseWriter, r *http.Request) {
+	h.atomFeed(w, r, "application/xml; charset=utf-8")
+}
+
+// atomFeed writes the Atom feed of the latest articles with the given content type
+func (h *Handlers) atomFeed(w http.ResponseWriter, r *http.Request, contentType string) {
 	articles, err := h.repository.GetArticles(r.Context(), 0, 20)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to fetch articles: %v", err), http.StatusInternalServerError)
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	if notModified(w, r, h.articlesValidator(mediaAtom, articles)) {
+		return
+	}
+
+	h.writeRendered(w, r, contentType, func(w io.Writer) error {
+		return h.views.AtomFeed(r.Context(), w, articles)
+	})
+}
+
+// ListTagsJSON returns a JSON list of tags with article counts
+func (h *Handlers) ListTagsJSON(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.repository.GetTags(r.Context())
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch tags: %w", err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if notModified(w, r, h.tagsValidator(mediaJSON, tags)) {
+		return
+	}
+
+	h.writeJSON(w, r, h.views.TagsFromCounts(tags))
+}
+
+// GetTagJSON returns the articles with a tag as JSON
+func (h *Handlers) GetTagJSON(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.repository.GetTag(r.Context(), chi.URLParam(r, "tag"))
+	if err != nil {
+		h.notFound(w, r, "tag not found")
+		return
+	}
+
+	articles, err := h.repository.GetArticlesByTag(r.Context(), tag.Slug, 0, 9999)
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if notModified(w, r, h.articlesValidator(mediaJSON, articles, tag.Slug, tag.Name)) {
+		return
+	}
+
+	h.writeJSON(w, r, h.views.TagFromArticles(tag, articles))
+}
+
+// ListTagsHTML returns an HTML index of all tags
+func (h *Handlers) ListTagsHTML(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.repository.GetTags(r.Context())
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch tags: %w", err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if notModified(w, r, h.tagsValidator(mediaHTML, tags)) {
+		return
+	}
+
+	h.writeRendered(w, r, "text/html; charset=utf-8", func(w io.Writer) error {
+		return h.views.Tags(r.Context(), w, h.views.TagsFromCounts(tags))
+	})
+}
+
+// GetTagHTML returns an HTML list of articles with a tag
+func (h *Handlers) GetTagHTML(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.repository.GetTag(r.Context(), chi.URLParam(r, "tag"))
+	if err != nil {
+		h.notFound(w, r, "")
+		return
+	}
+
+	articles, err := h.repository.GetArticlesByTag(r.Context(), tag.Slug, 0, 9999)
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if notModified(w, r, h.articlesValidator(mediaHTML, articles, tag.Slug, tag.Name)) {
+		return
+	}
+
+	h.writeRendered(w, r, "text/html; charset=utf-8", func(w io.Writer) error {
+		return h.views.Tag(r.Context(), w, h.views.TagFromArticles(tag, articles))
+	})
+}
+
+// GetTagFeed returns an Atom XML feed of articles with a tag
+func (h *Handlers) GetTagFeed(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.repository.GetTag(r.Context(), chi.URLParam(r, "tag"))
+	if err != nil {
+		h.notFound(w, r, "")
+		return
+	}
+
+	articles, err := h.repository.GetArticlesByTag(r.Context(), tag.Slug, 0, 20)
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 	w.Header().Set("Cache-Control", "public, max-age=3600")
 
-	if err := h.views.AtomFeed(r.Context(), w, articles); err != nil {
-		http.Error(w, fmt.Sprintf("feed generation failed: %v", err), http.StatusInternalServerError)
+	if notModified(w, r, h.articlesValidator(mediaAtom, articles, tag.Slug, tag.Name)) {
+		return
 	}
+
+	h.writeRendered(w, r, "application/xml; charset=utf-8", func(w io.Writer) error {
+		return h.views.TagFeed(r.Context(), w, tag, articles)
+	})
 }
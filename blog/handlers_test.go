@@ -1,9 +1,17 @@
 package blog
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	chi "github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
 )
 
 // TestHandlers_Structure validates the Handlers struct
@@ -58,3 +66,52 @@ func TestGetArticleHTML_FunctionExists(t *testing.T) {
 	h := &Handlers{}
 	assert.NotNil(t, h.GetArticleHTML)
 }
+
+// TestJSONArticleContent validates that only the single article response has the content
+func TestJSONArticleContent(t *testing.T) {
+	ctx := context.Background()
+	m := newTestModule(t, t.TempDir())
+
+	article := &model.Article{
+		ID:           "content",
+		Slug:         "content",
+		Title:        "Content",
+		Date:         timePtr(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)),
+		BodyMarkdown: "## Body markdown",
+		BodyHTML:     `<h2 id="body-markdown">Body markdown</h2>`,
+		TOC:          `[{"id":"body-markdown","title":"Body markdown","level":2}]`,
+	}
+	require.NoError(t, m.repository.InsertArticle(ctx, article))
+	require.NoError(t, m.repository.IndexArticleBody(ctx, "content", "Body markdown"))
+	require.NoError(t, m.repository.SetArticleTags(ctx, "content", []string{"go"}))
+
+	h := &Handlers{repository: m.repository}
+	router := chi.NewRouter()
+	router.Get("/api/blog/articles", h.ListArticlesJSON)
+	router.Get("/api/blog/articles/{slug}", h.GetArticleJSON)
+	router.Get("/api/blog/search", h.SearchArticlesJSON)
+	router.Get("/api/blog/tags/{tag}", h.GetTagJSON)
+
+	get := func(t *testing.T, url string) string {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `"Slug":"content"`)
+		return w.Body.String()
+	}
+
+	for _, url := range []string{"/api/blog/articles", "/api/blog/search?q=body", "/api/blog/tags/go"} {
+		body := get(t, url)
+		require.NotContains(t, body, "BodyMarkdown", url)
+		require.NotContains(t, body, "BodyHTML", url)
+		require.NotContains(t, body, "TOC", url)
+	}
+	require.Contains(t, get(t, "/api/blog/search?q=body"), `"Snippet":"\u003cmark\u003eBody\u003c/mark\u003e markdown"`)
+
+	body := get(t, "/api/blog/articles/content")
+	require.Contains(t, body, `"BodyMarkdown":"## Body markdown"`)
+	require.Contains(t, body, `"BodyHTML"`)
+	require.Contains(t, body, `"TOC"`)
+}
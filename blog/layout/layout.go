@@ -3,20 +3,29 @@ package layout
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/fs"
 	"log"
+	"maps"
+	"path"
+	"slices"
+	"strings"
 
 	"github.com/titpetric/vuego"
 )
 
+// baseLayout wraps templates outside of layouts/ that don't set a layout
+const baseLayout = "base"
+
 // Renderer handles page and layout rendering with vuego templates
 type Renderer struct {
 	root fs.FS
 	data map[string]any
 }
 
-// NewRenderer creates a new Renderer with the given filesystem and shared data
+// NewRenderer creates a new Renderer with the given filesystem and shared data.
+// The shared data is available to every template and must not be modified.
 func NewRenderer(root fs.FS, data map[string]any) *Renderer {
 	return &Renderer{
 		root: root,
@@ -24,45 +33,68 @@ func NewRenderer(root fs.FS, data map[string]any) *Renderer {
 	}
 }
 
-// template creates a vuego template with shared data and custom functions
-func (r *Renderer) template(data map[string]any) vuego.Template {
-	tpl := vuego.NewFS(r.root, vuego.WithLessProcessor())
-	return tpl.Funcs(Funcs).Fill(data)
+// template creates a vuego template with custom functions, rendering the scope
+func (r *Renderer) template(scope map[string]any) vuego.Template {
+	tpl := vuego.NewFS(r.root, vuego.WithProcessor(lessProcessor{vuego.NewLessProcessor(r.root)}))
+	return tpl.Funcs(Funcs).Fill(scope)
 }
 
 // Render loads a template, and if the template contains "layout" in the metadata, it will
 // load another template from layouts/%s.vuego; Layouts can be chained so one layout can
 // again trigger another layout, like `blog.vuego -> layouts/post.vuego -> layouts/base.vuego`.
+// Templates outside of layouts/ without a layout are wrapped in layouts/base.vuego.
+//
+// Every template renders its own scope. The scope of the first template holds the shared
+// data of the Renderer, overridden by data. Each template merges its front matter over the
+// scope, and the layout gets that scope with the rendered `content`, so front matter like
+// a page title is passed from child to parent layouts, and a layout's own front matter
+// takes precedence in the layout. Neither data nor the shared data are modified, so the
+// Renderer is safe for concurrent use.
 func (r *Renderer) Render(ctx context.Context, w io.Writer, filename string, data map[string]any) error {
-	tpl := r.template(data)
+	scope := make(map[string]any, len(r.data)+len(data))
+	maps.Copy(scope, r.data)
+	maps.Copy(scope, data)
+
+	chain := []string{filename}
 	for {
+		// Loading the template merges its front matter into the scope
+		tpl := r.template(scope).Load(filename)
+		layout := tpl.Get("layout")
+
 		var buf bytes.Buffer
-		if err := tpl.Load(filename).Render(ctx, &buf); err != nil {
+		if err := tpl.Render(ctx, &buf); err != nil {
 			return err
 		}
 
-		log.Printf("Render: %s %q", filename, tpl.Get("layout"))
-
-		content := buf.String()
-		data["content"] = content
+		log.Printf("Render: %s %q", filename, layout)
 
-		layout := tpl.Get("layout")
 		if layout == "" {
-			filename = "layouts/base.vuego"
-			break
+			if strings.HasPrefix(filename, "layouts/") {
+				_, err := buf.WriteTo(w)
+				return err
+			}
+			layout = baseLayout
 		}
 
-		delete(data, "layout")
-		tpl = r.template(data)
-		filename = "layouts/" + layout + ".vuego"
-		continue
-	}
-	log.Printf("Render: %s %q", filename, tpl.Get("layout"))
+		filename = "layouts/" + path.Clean(layout) + ".vuego"
+		if slices.Contains(chain, filename) {
+			return fmt.Errorf("layout cycle: %s -> %s", strings.Join(chain, " -> "), filename)
+		}
+		chain = append(chain, filename)
 
-	var buf bytes.Buffer
-	if err := tpl.Load(filename).Render(ctx, &buf); err != nil {
-		return err
+		scope = maps.Clone(scope)
+		delete(scope, "layout")
+		scope["content"] = buf.String()
 	}
-	_, err := io.Copy(w, &buf)
-	return err
+}
+
+// RenderComponent renders a single template with the shared data, overridden
+// by data, without wrapping it in a layout. Components embedded in content,
+// like shortcodes of articles, render this way.
+func (r *Renderer) RenderComponent(ctx context.Context, w io.Writer, filename string, data map[string]any) error {
+	scope := make(map[string]any, len(r.data)+len(data))
+	maps.Copy(scope, r.data)
+	maps.Copy(scope, data)
+
+	return r.template(scope).Load(filename).Render(ctx, w)
 }
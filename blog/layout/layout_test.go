@@ -5,6 +5,8 @@ import (
 	"context"
 	"embed"
 	"io/fs"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,5 +45,70 @@ func TestRenderLayout(t *testing.T) {
 		assert.Contains(t, output, ">Layout: base<")
 		assert.Contains(t, output, ">Layout: post<")
 		assert.Contains(t, output, ">Test Content<")
+		assert.Equal(t, 1, strings.Count(output, "<html"), "base layout must wrap once")
 	})
+
+	t.Run("front matter", func(t *testing.T) {
+		renderer := NewRenderer(fsys, map[string]any{
+			"meta":  map[string]any{"lang": "en"},
+			"title": "Site",
+		})
+
+		var buf bytes.Buffer
+		err := renderer.Render(ctx, &buf, "about.vuego", nil)
+		assert.NoError(t, err)
+
+		output := buf.String()
+		assert.Contains(t, output, `lang="en"`)
+		assert.Contains(t, output, "<header>About</header>", "page front matter is passed to layouts")
+		assert.Contains(t, output, "flow prose", "layout front matter is passed to parent layouts")
+		assert.Contains(t, output, "About the author.")
+	})
+
+	t.Run("component", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := renderer.RenderComponent(ctx, &buf, "blog.vuego", map[string]any{
+			"content": "Test Content",
+		})
+		assert.NoError(t, err)
+
+		output := buf.String()
+		assert.Contains(t, output, ">Test Content<")
+		assert.NotContains(t, output, "Layout: base", "components aren't wrapped in layouts")
+	})
+
+	t.Run("layout cycle", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := renderer.Render(ctx, &buf, "loop.vuego", nil)
+		assert.EqualError(t, err, "layout cycle: loop.vuego -> layouts/cycle.vuego -> layouts/loop.vuego -> layouts/cycle.vuego")
+		assert.Zero(t, buf.Len())
+	})
+}
+
+func TestRenderConcurrent(t *testing.T) {
+	fsys, err := fs.Sub(testdata, "testdata")
+	assert.NoError(t, err)
+
+	shared := map[string]any{
+		"meta": map[string]any{"lang": "en"},
+	}
+	renderer := NewRenderer(fsys, shared)
+	ctx := context.Background()
+
+	data := map[string]any{
+		"content": "Test Content",
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			var buf bytes.Buffer
+			assert.NoError(t, renderer.Render(ctx, &buf, "index.vuego", data))
+			assert.Contains(t, buf.String(), ">Layout: post<")
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]any{"content": "Test Content"}, data, "data must not be modified")
+	assert.Equal(t, map[string]any{"meta": map[string]any{"lang": "en"}}, shared, "shared data must not be modified")
 }
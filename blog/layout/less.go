@@ -0,0 +1,30 @@
+package layout
+
+import (
+	"sync"
+
+	"github.com/titpetric/vuego"
+	"golang.org/x/net/html"
+)
+
+// lessMu serializes LESS compilation, as the compiler keeps state in package variables
+var lessMu sync.Mutex
+
+// lessProcessor compiles LESS styles with the vuego LessProcessor,
+// one template at a time, so templates can render concurrently
+type lessProcessor struct {
+	vuego.NodeProcessor
+}
+
+// New returns a lessProcessor for a single render
+func (p lessProcessor) New() vuego.NodeProcessor {
+	return lessProcessor{p.NodeProcessor.New()}
+}
+
+// PostProcess compiles the LESS styles in the rendered nodes
+func (p lessProcessor) PostProcess(nodes []*html.Node) error {
+	lessMu.Lock()
+	defer lessMu.Unlock()
+
+	return p.NodeProcessor.PostProcess(nodes)
+}
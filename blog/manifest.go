@@ -0,0 +1,275 @@
+package blog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// manifestFile is the build manifest, kept in the output directory
+const manifestFile = ".manifest.json"
+
+// manifestVersion changes when the inputs recorded in the manifest change
+// meaning, so outputs of an older generator are rendered again
+const manifestVersion = 1
+
+// manifest records the hash of the inputs of each generated file, so an
+// incremental run only renders files whose inputs changed
+type manifest struct {
+	Version int `json:"version"`
+
+	// Outputs maps generated files, relative to the output directory, to the hash of their inputs
+	Outputs map[string]string `json:"outputs"`
+}
+
+// newManifest returns an empty manifest
+func newManifest() *manifest {
+	return &manifest{
+		Version: manifestVersion,
+		Outputs: make(map[string]string),
+	}
+}
+
+// readManifest reads a manifest, returning an empty manifest if the
+// file doesn't exist or was written by another manifest version
+func readManifest(filename string) (*manifest, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return newManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := newManifest()
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", filename, err)
+	}
+	if result.Version != manifestVersion || result.Outputs == nil {
+		return newManifest(), nil
+	}
+	return result, nil
+}
+
+// write stores the manifest in filename
+func (m *manifest) write(filename string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// buildChanges lists the outputs a generator run changed
+type buildChanges struct {
+	Added     []string
+	Updated   []string
+	Removed   []string
+	Unchanged int
+}
+
+// String summarizes the changes, e.g. `2 added, 1 updated, 0 removed, 40 unchanged`
+func (c buildChanges) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d removed, %d unchanged", len(c.Added), len(c.Updated), len(c.Removed), c.Unchanged)
+}
+
+// Print lists the changed files in a stable order
+func (c buildChanges) Print() {
+	for _, change := range []struct {
+		label string
+		names []string
+	}{{"Added", c.Added}, {"Updated", c.Updated}, {"Removed", c.Removed}} {
+		names := slices.Sorted(slices.Values(change.names))
+		for _, name := range names {
+			fmt.Printf("%s %s\n", change.label, name)
+		}
+	}
+}
+
+// buildInputs are the hashed inputs of the generated files
+type buildInputs struct {
+	// Site covers the theme and the site data files, which every page depends on
+	Site string
+
+	// Content covers all articles with their tags, and the redirects, which lists depend on
+	Content string
+
+	// Articles maps article IDs to the hash of the article and its tags
+	Articles map[string]string
+}
+
+// inputHash hashes the parts identifying the inputs of an output
+func inputHash(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// readInputs hashes the inputs of the site. The stored times and the file
+// names of articles aren't rendered and change when articles are indexed
+// into a new database or from another directory, so they are left out.
+func (g *Generator) readInputs(ctx context.Context, h *Handlers) (*buildInputs, error) {
+	theme, err := hashFS(g.module.themeFS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Site data is loaded from the config directory of the working directory
+	var data string
+	if _, err := os.Stat("config"); err == nil {
+		if data, err = hashFS(os.DirFS("config")); err != nil {
+			return nil, err
+		}
+	}
+
+	articles, err := h.repository.WithUnlisted().GetArticles(ctx, 0, 9999)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &buildInputs{
+		Site:     inputHash(theme, data),
+		Articles: make(map[string]string, len(articles)),
+	}
+
+	content := []string{result.Site}
+	for _, article := range articles {
+		tags, err := h.repository.GetArticleTags(ctx, article.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		stored := article
+		stored.CreatedAt, stored.UpdatedAt, stored.Filename = nil, nil, ""
+		encoded, err := json.Marshal(struct {
+			Article model.Article
+			Tags    []model.Tag
+		}{stored, tags})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Articles[article.ID] = inputHash(string(encoded))
+		content = append(content, result.Articles[article.ID])
+	}
+
+	redirects, err := h.repository.GetRedirects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, redirect := range redirects {
+		content = append(content, redirect.Path, redirect.Target)
+	}
+
+	result.Content = inputHash(content...)
+	return result, nil
+}
+
+// write generates the output file name, a slash separated path relative
+// to the output directory. When Generate runs, the file is rendered by a
+// worker and errors are reported when the workers finish.
+func (g *Generator) write(name, inputs string, render func(io.Writer) error) error {
+	g.mu.Lock()
+	g.current.Outputs[name] = inputs
+	g.mu.Unlock()
+
+	if g.workers == nil {
+		return g.writeFile(name, inputs, render)
+	}
+	g.workers.Go(name, func() error {
+		return g.writeFile(name, inputs, render)
+	})
+	return nil
+}
+
+// writeFile renders an output file. Incremental runs skip rendering if
+// the inputs are the same as in the previous run, and files with
+// unchanged contents aren't written again.
+func (g *Generator) writeFile(name, inputs string, render func(io.Writer) error) error {
+	outputPath := filepath.Join(g.outputDir, filepath.FromSlash(name))
+	_, statErr := os.Stat(outputPath)
+	exists := statErr == nil
+
+	previous, built := g.previous.Outputs[name]
+	if g.incremental && built && previous == inputs && inputs != "" && exists {
+		g.unchanged()
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+
+	if exists {
+		if current, err := os.ReadFile(outputPath); err == nil && bytes.Equal(current, buf.Bytes()) {
+			g.unchanged()
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if exists {
+		g.changes.Updated = append(g.changes.Updated, name)
+	} else {
+		g.changes.Added = append(g.changes.Added, name)
+	}
+	return nil
+}
+
+// unchanged counts an output file that didn't change
+func (g *Generator) unchanged() {
+	g.mu.Lock()
+	g.changes.Unchanged++
+	g.mu.Unlock()
+}
+
+// removeStale removes the files generated by the previous run but not by
+// this one, because their sources were deleted, along with directories
+// left empty
+func (g *Generator) removeStale() error {
+	var stale []string
+	for name := range g.previous.Outputs {
+		if _, ok := g.current.Outputs[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+
+	root := filepath.Clean(g.outputDir)
+	for _, name := range stale {
+		outputPath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.Remove(outputPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		g.changes.Removed = append(g.changes.Removed, name)
+
+		// Remove parent directories until one isn't empty
+		for dir := filepath.Dir(outputPath); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
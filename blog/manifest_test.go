@@ -0,0 +1,83 @@
+package blog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementalWrite(t *testing.T) {
+	outputDir := t.TempDir()
+	manifestPath := filepath.Join(outputDir, manifestFile)
+
+	// run generates the outputs, counting the renders, like Generate does
+	run := func(outputs map[string]string) (*Generator, int) {
+		previous, err := readManifest(manifestPath)
+		require.NoError(t, err)
+
+		g := NewGenerator(nil, outputDir)
+		g.SetIncremental(true)
+		g.previous = previous
+
+		var rendered int
+		for name, inputs := range outputs {
+			err := g.write(name, inputs, func(w io.Writer) error {
+				rendered++
+				_, err := io.WriteString(w, name+" "+inputs)
+				return err
+			})
+			require.NoError(t, err)
+		}
+		require.NoError(t, g.removeStale())
+		require.NoError(t, g.current.write(manifestPath))
+		return g, rendered
+	}
+
+	g, rendered := run(map[string]string{
+		"index.html":              "a",
+		"blog/hello/index.html":   "b",
+		"blog/removed/index.html": "c",
+	})
+	require.Equal(t, 3, rendered)
+	require.Len(t, g.changes.Added, 3)
+
+	t.Run("skips unchanged inputs", func(t *testing.T) {
+		g, rendered := run(map[string]string{
+			"index.html":              "a",
+			"blog/hello/index.html":   "b2",
+			"blog/removed/index.html": "c",
+		})
+		require.Equal(t, 1, rendered)
+		require.Equal(t, []string{"blog/hello/index.html"}, g.changes.Updated)
+		require.Equal(t, 2, g.changes.Unchanged)
+	})
+
+	t.Run("removes deleted outputs", func(t *testing.T) {
+		g, rendered := run(map[string]string{
+			"index.html":            "a",
+			"blog/hello/index.html": "b2",
+		})
+		require.Zero(t, rendered)
+		require.Equal(t, []string{"blog/removed/index.html"}, g.changes.Removed)
+		require.Equal(t, "0 added, 0 updated, 1 removed, 2 unchanged", g.changes.String())
+
+		_, err := os.Stat(filepath.Join(outputDir, "blog", "removed"))
+		require.True(t, os.IsNotExist(err), "empty directories are removed")
+		_, err = os.Stat(filepath.Join(outputDir, "blog", "hello", "index.html"))
+		require.NoError(t, err)
+	})
+
+	t.Run("renders missing outputs", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(outputDir, "index.html")))
+
+		g, rendered := run(map[string]string{
+			"index.html":            "a",
+			"blog/hello/index.html": "b2",
+		})
+		require.Equal(t, 1, rendered)
+		require.Equal(t, []string{"index.html"}, g.changes.Added)
+	})
+}
@@ -0,0 +1,201 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	chroma "github.com/alecthomas/chroma/v2"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// diffPrefix marks a diff of code in another language, e.g. `diff-go`
+const diffPrefix = "diff-"
+
+// codeOptions are the attributes of a fenced code block, given after the
+// language in the info string:
+//
+//	```go {title="main.go" hl_lines="3-5" linenos=true}
+type codeOptions struct {
+	// title is the caption of the code block, usually a file name
+	title string
+
+	// lineNumbers numbers the lines, starting with lineStart
+	lineNumbers bool
+	lineStart   int
+
+	// highlight are the ranges of emphasised lines, inclusive. Lines are
+	// counted from 1 for the first line, regardless of lineStart.
+	highlight [][2]int
+
+	// diff colours added and removed lines, marked with `+` and `-`
+	diff bool
+}
+
+// wrapLines returns true if the lines of the code block are rendered
+// separately, for line numbers, highlighted lines or diff colours
+func (o codeOptions) wrapLines() bool {
+	return o.lineNumbers || len(o.highlight) > 0 || o.diff
+}
+
+// highlighted returns true if the line is in a highlighted range
+func (o codeOptions) highlighted(line int) bool {
+	for _, r := range o.highlight {
+		if line >= r[0] && line <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCodeOptions reads the attributes from the info string of a fenced
+// code block. Unknown attributes and invalid values are ignored.
+func parseCodeOptions(info []byte) codeOptions {
+	opts := codeOptions{lineStart: 1}
+
+	i := bytes.IndexByte(info, '{')
+	if i < 0 {
+		return opts
+	}
+	attrs, ok := parser.ParseAttributes(text.NewReader(info[i:]))
+	if !ok {
+		return opts
+	}
+
+	for _, attr := range attrs {
+		switch string(attr.Name) {
+		case "title":
+			if value, ok := attr.Value.([]byte); ok {
+				opts.title = string(value)
+			}
+		case "linenos":
+			if value, ok := attr.Value.(bool); ok {
+				opts.lineNumbers = value
+			}
+		case "linenostart":
+			if value, ok := attr.Value.(float64); ok && value >= 0 {
+				opts.lineStart = int(value)
+			}
+		case "hl_lines":
+			opts.highlight = parseLineRanges(attr.Value)
+		}
+	}
+	return opts
+}
+
+// parseLineRanges reads line ranges like `"1 3-5"`, `"1,3-5"` or `[1, "3-5"]`
+func parseLineRanges(value any) [][2]int {
+	var fields []string
+	switch value := value.(type) {
+	case []byte:
+		fields = strings.FieldsFunc(string(value), func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+	case float64:
+		fields = []string{strconv.Itoa(int(value))}
+	case []any:
+		for _, item := range value {
+			switch item := item.(type) {
+			case []byte:
+				fields = append(fields, string(item))
+			case float64:
+				fields = append(fields, strconv.Itoa(int(item)))
+			}
+		}
+	}
+
+	var result [][2]int
+	for _, field := range fields {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil || end < start {
+				continue
+			}
+		}
+		result = append(result, [2]int{start, end})
+	}
+	return result
+}
+
+// diffMarkers removes the `+`, `-` or space marking each line of a diff,
+// so the code can be highlighted in its language, and returns the markers
+func diffMarkers(code []byte) ([]byte, []byte) {
+	lines := bytes.SplitAfter(code, []byte("\n"))
+	markers := make([]byte, 0, len(lines))
+
+	result := make([]byte, 0, len(code))
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		marker := byte(0)
+		if line[0] == '+' || line[0] == '-' || line[0] == ' ' {
+			marker, line = line[0], line[1:]
+		}
+		markers = append(markers, marker)
+		result = append(result, line...)
+	}
+	return result, markers
+}
+
+// writeLines writes highlighted code line by line, with the line classes of
+// Chroma, so the generated stylesheets style line numbers, highlighted lines
+// and diff lines. markers holds the diff marker of each line, if it was
+// removed before highlighting.
+func writeLines(buf *bytes.Buffer, tokens []chroma.Token, opts codeOptions, markers []byte) error {
+	lines := chroma.SplitTokensIntoLines(tokens)
+	digits := len(strconv.Itoa(opts.lineStart + len(lines) - 1))
+	formatter := newFormatter()
+
+	for index, line := range lines {
+		// Splitting tokens at line ends leaves empty tokens
+		line = slices.DeleteFunc(line, func(token chroma.Token) bool {
+			return token.Value == ""
+		})
+
+		marker := byte(0)
+		if index < len(markers) {
+			marker = markers[index]
+		}
+		if opts.diff && marker == 0 && len(line) > 0 {
+			// A `diff` block keeps the markers in the code
+			if value := line[0].Value; value != "" {
+				marker = value[0]
+			}
+		}
+
+		class := "line"
+		if opts.highlighted(index + 1) {
+			class += " hl"
+		}
+		switch {
+		case !opts.diff:
+		case marker == '+':
+			class += " gi"
+		case marker == '-':
+			class += " gd"
+		}
+
+		fmt.Fprintf(buf, `<span class="%s">`, class)
+		if opts.lineNumbers {
+			fmt.Fprintf(buf, `<span class="ln">%*d</span>`, digits, opts.lineStart+index)
+		}
+		buf.WriteString(`<span class="cl">`)
+		if index < len(markers) && markers[index] != 0 {
+			buf.WriteString(escapeHTML(string(markers[index])))
+		}
+		if err := formatter.Format(buf, defaultStyle(), chroma.Literator(line...)); err != nil {
+			return err
+		}
+		buf.WriteString("</span></span>")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCodeOptions(t *testing.T) {
+	tests := map[string]codeOptions{
+		"go":    {lineStart: 1},
+		"go {}": {lineStart: 1},
+		`go {title="main.go" hl_lines="3-5" linenos=true}`: {
+			title:       "main.go",
+			lineNumbers: true,
+			lineStart:   1,
+			highlight:   [][2]int{{3, 5}},
+		},
+		`go {hl_lines="1 3-4,7" linenostart=10}`: {
+			lineStart: 10,
+			highlight: [][2]int{{1, 1}, {3, 4}, {7, 7}},
+		},
+		`go {hl_lines=[2, "4-5"]}`: {
+			lineStart: 1,
+			highlight: [][2]int{{2, 2}, {4, 5}},
+		},
+		`go {hl_lines="x 5-3 2" linenos="yes" unknown=1}`: {
+			lineStart: 1,
+			highlight: [][2]int{{2, 2}},
+		},
+	}
+	for info, want := range tests {
+		if got := parseCodeOptions([]byte(info)); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseCodeOptions(%q) = %+v, want %+v", info, got, want)
+		}
+	}
+}
+
+func TestCodeBlockTitle(t *testing.T) {
+	renderer := NewRenderer()
+
+	result := string(renderer.Render([]byte("```go {title=\"cmd/<main>.go\"}\npackage main\n```\n")))
+
+	want := "<figure class=\"code-block\">\n<figcaption class=\"code-title\">cmd/&lt;main&gt;.go</figcaption>\n<pre class=\"chroma language-go\">"
+	if !strings.HasPrefix(result, want) {
+		t.Errorf("expected a captioned code block, got:\n%s", result)
+	}
+	if !strings.HasSuffix(result, "</pre>\n</figure>\n") {
+		t.Errorf("expected the figure to close after the code, got:\n%s", result)
+	}
+	if strings.Contains(result, `class="line"`) {
+		t.Errorf("expected the lines of a code block with only a title to not be wrapped, got:\n%s", result)
+	}
+}
+
+func TestCodeBlockLines(t *testing.T) {
+	renderer := NewRenderer()
+	markdown := []byte("```go {hl_lines=\"2-3\" linenos=true linenostart=9}\na := 1\nb := 2\nc := 3\nd := 4\n```\n")
+
+	result := string(renderer.Render(markdown))
+
+	if got := strings.Count(result, `<span class="line`); got != 4 {
+		t.Errorf("expected 4 lines, got %d:\n%s", got, result)
+	}
+	if got := strings.Count(result, `<span class="line hl">`); got != 2 {
+		t.Errorf("expected 2 highlighted lines, got %d:\n%s", got, result)
+	}
+	for _, want := range []string{
+		`<span class="line"><span class="ln"> 9</span><span class="cl"><span class="nx">a</span>`,
+		`<span class="line hl"><span class="ln">10</span><span class="cl"><span class="nx">b</span>`,
+		`<span class="line hl"><span class="ln">11</span>`,
+		`<span class="line"><span class="ln">12</span>`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %s, got:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "<span class=\"w\"></span>") {
+		t.Errorf("expected no empty tokens, got:\n%s", result)
+	}
+
+	// Highlighted lines don't need line numbers
+	result = string(renderer.Render([]byte("```go {hl_lines=1}\na := 1\nb := 2\n```\n")))
+	if strings.Contains(result, `class="ln"`) || !strings.Contains(result, `<span class="line hl"><span class="cl">`) {
+		t.Errorf("expected a highlighted line without numbers, got:\n%s", result)
+	}
+}
+
+func TestCodeBlockDiff(t *testing.T) {
+	renderer := NewRenderer()
+
+	t.Run("language", func(t *testing.T) {
+		markdown := []byte("```diff-go\n func main() {\n-\tprintln(1)\n+\tprintln(2)\n }\n```\n")
+
+		result := string(renderer.Render(markdown))
+
+		if !strings.Contains(result, `<pre class="chroma language-diff-go">`) {
+			t.Errorf("expected the language of the info string, got:\n%s", result)
+		}
+		for _, want := range []string{
+			`<span class="line"><span class="cl"> <span class="kd">func</span>`,
+			`<span class="line gd"><span class="cl">-<span class="w">	</span><span class="nb">println</span>`,
+			`<span class="line gi"><span class="cl">+<span class="w">	</span><span class="nb">println</span>`,
+		} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %s, got:\n%s", want, result)
+			}
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		markdown := []byte("```diff\n--- a.txt\n+++ b.txt\n@@ -1 +1 @@\n-old\n+new\n```\n")
+
+		result := string(renderer.Render(markdown))
+
+		for _, want := range []string{
+			`<span class="line gd"><span class="cl"><span class="gd">--- a.txt`,
+			`<span class="line gi"><span class="cl"><span class="gi">+++ b.txt`,
+			`<span class="line"><span class="cl"><span class="gu">@@ -1 +1 @@`,
+			`<span class="line gd"><span class="cl"><span class="gd">-old`,
+			`<span class="line gi"><span class="cl"><span class="gi">+new`,
+		} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %s, got:\n%s", want, result)
+			}
+		}
+	})
+}
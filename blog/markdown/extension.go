@@ -0,0 +1,54 @@
+package markdown
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension extends the markdown renderer. Extend registers goldmark
+// parsers, AST transformers or node renderers with the renderer.
+type Extension interface {
+	goldmark.Extender
+
+	// Name identifies the extension and the version of its output, e.g.
+	// `shortcodes/1`. Names are part of the renderer version, so
+	// changing one renders cached HTML again.
+	Name() string
+}
+
+// transformPriority runs transforms after the transformers of goldmark
+const transformPriority = 1000
+
+// transform is an extension calling a function with the parsed document
+type transform struct {
+	name string
+	fn   func(doc *ast.Document, source []byte)
+}
+
+// NewTransform returns an extension calling fn with every parsed document
+// before it's rendered. fn may add, change or remove nodes; source holds
+// the markdown that node segments point into.
+func NewTransform(name string, fn func(doc *ast.Document, source []byte)) Extension {
+	return &transform{
+		name: name,
+		fn:   fn,
+	}
+}
+
+// Name returns the name of the transform
+func (t *transform) Name() string {
+	return t.name
+}
+
+// Extend registers the transform as a goldmark AST transformer
+func (t *transform) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(t, transformPriority)))
+}
+
+// Transform implements parser.ASTTransformer
+func (t *transform) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	t.fn(doc, reader.Source())
+}
@@ -0,0 +1,178 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	chroma "github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// codeBlocks is the extension highlighting fenced and indented code blocks
+type codeBlocks struct{}
+
+// Extend registers the code block renderer over the goldmark defaults
+func (codeBlocks) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(codeBlocks{}, 100)))
+}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (c codeBlocks) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, c.render)
+	reg.Register(ast.KindCodeBlock, c.render)
+}
+
+// render writes a highlighted code block. The code is read from the
+// source segments of the node, so it's never escaped before highlighting.
+func (codeBlocks) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var language string
+	opts := codeOptions{lineStart: 1}
+	if fenced, ok := node.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		language = string(fenced.Language(source))
+		opts = parseCodeOptions(fenced.Info.Segment.Value(source))
+	}
+
+	var code bytes.Buffer
+	lines := node.Lines()
+	for i := range lines.Len() {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	if _, err := w.Write(highlightBlock(code.Bytes(), language, opts)); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// highlightCode applies syntax highlighting to code using Chroma. Tokens
+// get Chroma classes, styled by the stylesheets from Stylesheet.
+func highlightCode(code []byte, language string) []byte {
+	return highlightBlock(code, language, codeOptions{lineStart: 1})
+}
+
+// highlightBlock highlights code like highlightCode, with the options of the
+// code block. A title adds a caption, and line numbers, highlighted lines
+// and diffs render every line separately. `diff` blocks are highlighted as
+// diffs, `diff-<language>` blocks are highlighted in the language.
+func highlightBlock(code []byte, language string, opts codeOptions) []byte {
+	if len(code) == 0 {
+		return withTitle([]byte("<pre><code></code></pre>\n"), opts.title)
+	}
+
+	// Diff markers are removed to highlight the code in its language
+	lexerName := language
+	var markers []byte
+	switch {
+	case language == "diff":
+		opts.diff = true
+	case strings.HasPrefix(language, diffPrefix):
+		opts.diff = true
+		lexerName = strings.TrimPrefix(language, diffPrefix)
+		code, markers = diffMarkers(code)
+	}
+
+	codeStr := string(code)
+
+	// Select lexer for the language
+	var lexer chroma.Lexer
+	if lexerName != "" {
+		lexer = lexers.Get(lexerName)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(codeStr)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	// Tokenize
+	iterator, err := lexer.Tokenise(nil, codeStr)
+	if err != nil {
+		// On error, return plain code block
+		return withTitle(wrapCodePlain(code, language), opts.title)
+	}
+
+	var buf bytes.Buffer
+	if opts.wrapLines() {
+		err = writeLines(&buf, iterator.Tokens(), opts, markers)
+	} else {
+		err = newFormatter().Format(&buf, defaultStyle(), iterator)
+	}
+	if err != nil {
+		return withTitle(wrapCodePlain(code, language), opts.title)
+	}
+
+	// Don't strip newlines - preserve all whitespace as-is
+	formattedCode := buf.String()
+
+	// Wrap highlighted code in pre/code tags with chroma and language classes
+	languageClass := ""
+	if language != "" {
+		languageClass = fmt.Sprintf(` language-%s`, escapeHTML(language))
+	}
+
+	result := fmt.Sprintf(
+		"<pre class=\"chroma%s\"><code>%s</code></pre>\n",
+		languageClass,
+		formattedCode,
+	)
+
+	return withTitle([]byte(result), opts.title)
+}
+
+// withTitle adds the title of a code block as the caption of a figure
+func withTitle(block []byte, title string) []byte {
+	if title == "" {
+		return block
+	}
+	return []byte(fmt.Sprintf(
+		"<figure class=\"code-block\">\n<figcaption class=\"code-title\">%s</figcaption>\n%s</figure>\n",
+		escapeHTML(title),
+		block,
+	))
+}
+
+// newFormatter creates the Chroma formatter for code blocks, writing
+// classes instead of inline styles
+func newFormatter() *html.Formatter {
+	return html.New(
+		html.WithClasses(true),
+		html.PreventSurroundingPre(true),
+		html.TabWidth(4),
+	)
+}
+
+// wrapCodePlain wraps code without highlighting
+func wrapCodePlain(code []byte, language string) []byte {
+	languageClass := ""
+	if language != "" {
+		languageClass = fmt.Sprintf(` class="language-%s"`, escapeHTML(language))
+	}
+	return []byte(fmt.Sprintf(
+		"<pre><code%s>%s</code></pre>\n",
+		languageClass,
+		escapeHTML(string(code)),
+	))
+}
+
+// escapeHTML escapes HTML special characters
+func escapeHTML(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		"\"", "&quot;",
+		"'", "&#39;",
+	).Replace(s)
+}
@@ -2,168 +2,102 @@ package markdown
 
 import (
 	"bytes"
-	"fmt"
-	"regexp"
 	"strings"
 
-	chroma "github.com/alecthomas/chroma/v2"
-	"github.com/alecthomas/chroma/v2/formatters/html"
-	"github.com/alecthomas/chroma/v2/lexers"
-	"github.com/alecthomas/chroma/v2/styles"
-	blackfriday "github.com/russross/blackfriday/v2"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 )
 
-// Renderer renders markdown content to HTML with syntax highlighting
+// Version identifies the output of the renderer. Change it whenever the
+// rendered HTML changes, so cached HTML gets rendered again.
+const Version = "6"
+
+// Renderer renders markdown content to HTML with syntax highlighting.
+// Markdown is parsed to a CommonMark AST with the GitHub Flavored Markdown
+// extensions: tables, task lists, strikethrough, autolinks and footnotes.
+// Code blocks are highlighted when their nodes are rendered. Headings get
+// slugified ids, unique within the document.
+// A Renderer is safe for concurrent use.
 type Renderer struct {
-	// codeBlockPattern matches HTML code blocks for highlighting
-	codeBlockPattern *regexp.Regexp
+	markdown   goldmark.Markdown
+	extensions []Extension
 }
 
 // NewRenderer creates a new markdown renderer with syntax highlighting support
-func NewRenderer() *Renderer {
-	return &Renderer{
-		codeBlockPattern: regexp.MustCompile(`<pre><code(?:\s+class="language-([^"]+)")?>([.\s\S]*?)</code></pre>`),
+// and the given extensions, applied in order
+func NewRenderer(extensions ...Extension) *Renderer {
+	extenders := []goldmark.Extender{codeBlocks{}}
+	for _, ext := range extensions {
+		extenders = append(extenders, ext)
 	}
-}
-
-// Render converts markdown content to HTML with syntax highlighting for code blocks
-// Security: Blackfriday escapes HTML in code blocks, we unescape to get raw code,
-// then Chroma's formatter escapes it again when outputting HTML. This is safe.
-func (r *Renderer) Render(content []byte) []byte {
-	// First, render markdown to HTML using blackfriday
-	htmlContent := blackfriday.Run(content)
-
-	// Then apply syntax highlighting to code blocks
-	highlighted := r.highlightCodeBlocks(htmlContent)
-
-	return highlighted
-}
-
-// highlightCodeBlocks applies syntax highlighting to all code blocks in the HTML
-func (r *Renderer) highlightCodeBlocks(html []byte) []byte {
-	htmlStr := string(html)
-
-	// Replace all code blocks with highlighted versions
-	result := r.codeBlockPattern.ReplaceAllStringFunc(htmlStr, func(match string) string {
-		return r.processCodeBlock(match)
-	})
 
-	return []byte(result)
-}
-
-// processCodeBlock processes a single code block and applies syntax highlighting
-func (r *Renderer) processCodeBlock(match string) string {
-	// Extract language and code from the match
-	m := r.codeBlockPattern.FindStringSubmatch(match)
-	if len(m) < 3 {
-		return match
+	return &Renderer{
+		markdown:   newMarkdown(extenders...),
+		extensions: extensions,
 	}
-
-	language := m[1]
-	code := m[2]
-
-	// Unescape HTML entities in code (blackfriday escapes it, we need original for highlighting)
-	code = unescapeHTML(code)
-
-	// Apply syntax highlighting - Chroma handles escaping internally
-	highlighted := highlightCode([]byte(code), language)
-
-	return string(highlighted)
 }
 
-// highlightCode applies syntax highlighting to code using Chroma
-func highlightCode(code []byte, language string) []byte {
-	if len(code) == 0 {
-		return []byte("<pre><code></code></pre>")
-	}
-
-	codeStr := string(code)
-
-	// Select lexer for the language
-	var lexer chroma.Lexer
-	if language != "" {
-		lexer = lexers.Get(language)
-	}
-	if lexer == nil {
-		lexer = lexers.Analyse(codeStr)
-	}
-	if lexer == nil {
-		lexer = lexers.Fallback
-	}
-
-	// Tokenize
-	iterator, err := lexer.Tokenise(nil, codeStr)
-	if err != nil {
-		// On error, return plain code block
-		return wrapCodePlain(code, language)
-	}
-
-	// Format with HTML and Monokai style
-	style := styles.Get("monokai")
-	if style == nil {
-		style = styles.Fallback
-	}
-
-	formatter := html.New(
-		html.WithClasses(false),
-		html.PreventSurroundingPre(true),
-		html.TabWidth(4),
+// newMarkdown configures goldmark with the extensions of the site. Raw HTML
+// in articles is rendered as is, as articles embed theme components.
+func newMarkdown(extenders ...goldmark.Extender) goldmark.Markdown {
+	extenders = append([]goldmark.Extender{
+		extension.GFM,
+		extension.Footnote,
+	}, extenders...)
+
+	return goldmark.New(
+		goldmark.WithExtensions(extenders...),
+		goldmark.WithParserOptions(
+			parser.WithAttribute(),
+			parser.WithAutoHeadingID(),
+		),
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+			html.WithXHTML(),
+		),
 	)
+}
 
-	var buf bytes.Buffer
-	if err := formatter.Format(&buf, style, iterator); err != nil {
-		return wrapCodePlain(code, language)
+// Version identifies the output of the renderer with its extensions,
+// e.g. `2+shortcodes/1`, for caching rendered HTML
+func (r *Renderer) Version() string {
+	if len(r.extensions) == 0 {
+		return Version
 	}
 
-	// Don't strip newlines - preserve all whitespace as-is
-	formattedCode := buf.String()
-
-	// Wrap highlighted code in pre/code tags with chroma and language classes
-	languageClass := ""
-	if language != "" {
-		languageClass = fmt.Sprintf(` language-%s`, language)
+	names := make([]string, 0, len(r.extensions))
+	for _, ext := range r.extensions {
+		names = append(names, ext.Name())
 	}
-
-	result := fmt.Sprintf(
-		"<pre class=\"chroma%s\"><code>%s</code></pre>",
-		languageClass,
-		formattedCode,
-	)
-
-	return []byte(result)
+	return Version + "+" + strings.Join(names, ",")
 }
 
-// wrapCodePlain wraps code without highlighting
-func wrapCodePlain(code []byte, language string) []byte {
-	languageClass := ""
-	if language != "" {
-		languageClass = fmt.Sprintf(` class="language-%s"`, language)
-	}
-	return []byte(fmt.Sprintf(
-		"<pre><code%s>%s</code></pre>",
-		languageClass,
-		escapeHTML(string(code)),
-	))
+// Render converts markdown content to HTML with syntax highlighting for code blocks.
+// Code is highlighted from the source of the code block nodes, and Chroma escapes it.
+func (r *Renderer) Render(content []byte) []byte {
+	html, _ := r.RenderHeadings(content)
+	return html
 }
 
-// escapeHTML escapes HTML special characters
-func escapeHTML(s string) string {
-	return strings.NewReplacer(
-		"&", "&amp;",
-		"<", "&lt;",
-		">", "&gt;",
-		"\"", "&quot;",
-		"'", "&#39;",
-	).Replace(s)
+// RenderHeadings converts markdown content to HTML like Render, and returns
+// the headings of the content as a tree for a table of contents
+func (r *Renderer) RenderHeadings(content []byte) ([]byte, []*Heading) {
+	doc := r.markdown.Parser().Parse(text.NewReader(content), parser.WithContext(parserContext()))
+
+	buf := bytes.NewBuffer(make([]byte, 0, 2*len(content)))
+	if err := r.markdown.Renderer().Render(buf, content, doc); err != nil {
+		// Rendering to a buffer only fails if an extension fails
+		return []byte("<pre>" + escapeHTML(err.Error()) + "</pre>"), nil
+	}
+	return buf.Bytes(), headingTree(doc, content)
 }
 
-// unescapeHTML unescapes HTML special characters
-func unescapeHTML(s string) string {
-	return strings.NewReplacer(
-		"&amp;", "&",
-		"&lt;", "<",
-		"&gt;", ">",
-		"&quot;", "\"",
-		"&#39;", "'",
-	).Replace(s)
+// Headings returns the headings of markdown content as a tree, without
+// rendering it. The ids match the ids in the HTML of Render.
+func (r *Renderer) Headings(content []byte) []*Heading {
+	doc := r.markdown.Parser().Parse(text.NewReader(content), parser.WithContext(parserContext()))
+	return headingTree(doc, content)
 }
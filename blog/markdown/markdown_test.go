@@ -3,6 +3,8 @@ package markdown
 import (
 	"strings"
 	"testing"
+
+	"github.com/yuin/goldmark/ast"
 )
 
 func TestRenderSimpleMarkdown(t *testing.T) {
@@ -10,7 +12,7 @@ func TestRenderSimpleMarkdown(t *testing.T) {
 	markdown := []byte("# Hello\n\nThis is a **test**.")
 	result := renderer.Render(markdown)
 
-	if !strings.Contains(string(result), "<h1>") {
+	if !strings.Contains(string(result), `<h1 id="hello">`) {
 		t.Error("expected h1 tag in result")
 	}
 	if !strings.Contains(string(result), "<strong>test</strong>") {
@@ -97,3 +99,101 @@ func TestHighlightEmptyCode(t *testing.T) {
 		t.Error("expected pre tag")
 	}
 }
+
+func TestText(t *testing.T) {
+	content := []byte("# Title\n\nSome **bold** text with a [link](https://example.com).\n\n```go\nfunc main() {}\n```\n")
+
+	result := Text(content)
+
+	expected := "Title Some bold text with a link . func main() {}"
+	if result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+
+	// Shortcodes are left out, the markdown within them is text
+	content = []byte("Watch {{< icon name=\"play\" >}} this:\n\n{{< youtube id=\"abc\" >}}\n\n{{< info-cta >}}\nSubscribe to the **feed**.\n{{< /info-cta >}}\n\n`{{< kept >}}`\n")
+	result = Text(content)
+
+	expected = "Watch this: Subscribe to the feed . {{< kept >}}"
+	if result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+}
+
+func TestRenderGFM(t *testing.T) {
+	renderer := NewRenderer()
+
+	markdown := []byte(`| Name | Value |
+| ---- | ----- |
+| a    | 1     |
+
+- [x] done
+- [ ] todo
+
+This is ~~gone~~, see https://example.com.
+
+A note[^1].
+
+[^1]: The footnote.
+`)
+
+	result := string(renderer.Render(markdown))
+
+	for _, expected := range []string{
+		"<table>",
+		"<td>a</td>",
+		`<input checked="" disabled="" type="checkbox" />`,
+		"<del>gone</del>",
+		`<a href="https://example.com">https://example.com</a>`,
+		`<sup id="fnref:1">`,
+		`<div class="footnotes" role="doc-endnotes">`,
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected %q in result:\n%s", expected, result)
+		}
+	}
+}
+
+func TestRenderCodeBlockSource(t *testing.T) {
+	renderer := NewRenderer()
+
+	// Markup in code is highlighted from the source and escaped once
+	markdown := []byte("```html {title=\"index.html\"}\n<p class=\"note\">&amp; <b>bold</b></p>\n```\n")
+
+	result := string(renderer.Render(markdown))
+
+	if !strings.Contains(result, `<pre class="chroma language-html">`) {
+		t.Errorf("expected the language of the info string, got:\n%s", result)
+	}
+	if !strings.Contains(result, "&amp;amp;") {
+		t.Errorf("expected the entity in code to be escaped, got:\n%s", result)
+	}
+	if strings.Contains(result, "<b>") {
+		t.Errorf("expected markup in code to be escaped, got:\n%s", result)
+	}
+}
+
+func TestRenderExtension(t *testing.T) {
+	// Transform emphasis into strong emphasis
+	strong := NewTransform("strong/1", func(doc *ast.Document, source []byte) {
+		ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+			if emphasis, ok := node.(*ast.Emphasis); ok && entering {
+				emphasis.Level = 2
+			}
+			return ast.WalkContinue, nil
+		})
+	})
+
+	renderer := NewRenderer(strong)
+	result := string(renderer.Render([]byte("Some *text*.")))
+
+	if !strings.Contains(result, "<strong>text</strong>") {
+		t.Errorf("expected the transform to apply, got %s", result)
+	}
+	if renderer.Version() != Version+"+strong/1" {
+		t.Errorf("expected the extension in the version, got %s", renderer.Version())
+	}
+	if NewRenderer().Version() != Version {
+		t.Errorf("expected the version without extensions, got %s", NewRenderer().Version())
+	}
+}
@@ -0,0 +1,318 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// ShortcodeFunc renders the shortcode name with its attributes to HTML.
+// content holds the rendered markdown between a shortcode and its closing
+// shortcode, and is empty for shortcodes without one.
+type ShortcodeFunc func(name string, attrs map[string]string, content []byte) ([]byte, error)
+
+var (
+	// shortcodePattern matches a shortcode at the start of the text, like
+	// `{{< youtube id="..." >}}`, `{{< note />}}` or `{{< /note >}}`
+	shortcodePattern = regexp.MustCompile(`^\{\{<\s*(/?)([a-zA-Z][\w-]*)((?:\s+[a-zA-Z_][\w-]*(?:=(?:"[^"]*"|'[^']*'|[^\s"'/>]+))?)*)\s*(/?)>\}\}`)
+
+	// shortcodeAttribute matches an attribute of a shortcode, quoted or bare.
+	// Attributes without a value are `true`.
+	shortcodeAttribute = regexp.MustCompile(`([a-zA-Z_][\w-]*)(?:=(?:"([^"]*)"|'([^']*)'|([^\s"'/>]+)))?`)
+)
+
+// shortcode is a parsed shortcode tag
+type shortcode struct {
+	name  string
+	attrs map[string]string
+
+	// closing is set for `{{< /name >}}`, selfClosing for `{{< name />}}`
+	closing     bool
+	selfClosing bool
+
+	// source is the text of the tag, written as is if rendering fails
+	source []byte
+}
+
+// parseShortcode parses the shortcode tag at the start of text
+func parseShortcode(text []byte) (shortcode, bool) {
+	match := shortcodePattern.FindSubmatch(text)
+	if match == nil {
+		return shortcode{}, false
+	}
+
+	attrs := make(map[string]string)
+	for _, attr := range shortcodeAttribute.FindAllSubmatch(match[3], -1) {
+		value := "true"
+		if bytes.ContainsRune(attr[0], '=') {
+			value = string(attr[2]) + string(attr[3]) + string(attr[4])
+		}
+		attrs[string(attr[1])] = value
+	}
+
+	return shortcode{
+		name:        string(match[2]),
+		attrs:       attrs,
+		closing:     len(match[1]) > 0,
+		selfClosing: len(match[4]) > 0,
+		source:      match[0],
+	}, true
+}
+
+// Kinds of the shortcode nodes
+var (
+	kindShortcodeBlock  = ast.NewNodeKind("ShortcodeBlock")
+	kindShortcodeInline = ast.NewNodeKind("ShortcodeInline")
+)
+
+// shortcodeBlock is a shortcode on a line of its own. The blocks between
+// it and its closing shortcode are its children.
+type shortcodeBlock struct {
+	ast.BaseBlock
+	shortcode
+}
+
+// Kind implements ast.Node
+func (n *shortcodeBlock) Kind() ast.NodeKind {
+	return kindShortcodeBlock
+}
+
+// Dump implements ast.Node
+func (n *shortcodeBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.name}, nil)
+}
+
+// shortcodeInline is a shortcode within text, without content
+type shortcodeInline struct {
+	ast.BaseInline
+	shortcode
+}
+
+// Kind implements ast.Node
+func (n *shortcodeInline) Kind() ast.NodeKind {
+	return kindShortcodeInline
+}
+
+// Dump implements ast.Node
+func (n *shortcodeInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.name}, nil)
+}
+
+// shortcodes is the extension parsing and rendering shortcodes
+type shortcodes struct {
+	render ShortcodeFunc
+}
+
+// NewShortcodes returns an extension rendering shortcodes with render.
+// A shortcode on a line of its own renders as a block, and the markdown
+// up to its closing shortcode is rendered as its content:
+//
+//	{{< youtube id="dQw4w9WgXcQ" >}}
+//
+//	{{< info-cta >}}
+//	Markdown *content*.
+//	{{< /info-cta >}}
+//
+// Shortcodes within text render inline, without content. A shortcode
+// that fails to render is written as text.
+func NewShortcodes(render ShortcodeFunc) Extension {
+	return &shortcodes{
+		render: render,
+	}
+}
+
+// Name returns the name of the extension
+func (s *shortcodes) Name() string {
+	return "shortcodes/1"
+}
+
+// Extend registers the shortcode parsers and renderer
+func (s *shortcodes) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(shortcodeBlockParser{}, 850)),
+		parser.WithInlineParsers(util.Prioritized(shortcodeInlineParser{}, 450)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&shortcodeRenderer{
+		render:   s.render,
+		markdown: m,
+	}, 100)))
+}
+
+// shortcodeBlockParser parses shortcodes on lines of their own
+type shortcodeBlockParser struct{}
+
+// Trigger implements parser.BlockParser
+func (shortcodeBlockParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+// Open starts a shortcode block. A shortcode with a closing shortcode
+// further down the document holds the blocks up to it.
+func (shortcodeBlockParser) Open(_ ast.Node, reader text.Reader, _ parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	if width, _ := util.IndentWidth(line, reader.LineOffset()); width > 3 {
+		return nil, parser.NoChildren
+	}
+
+	tag := util.TrimRightSpace(util.TrimLeftSpace(line))
+	code, ok := parseShortcode(tag)
+	if !ok || code.closing || len(code.source) != len(tag) {
+		return nil, parser.NoChildren
+	}
+
+	reader.AdvanceToEOL()
+	node := &shortcodeBlock{shortcode: code}
+	if code.selfClosing || !hasClosingShortcode(reader.Source()[segment.Stop:], code.name) {
+		node.selfClosing = true
+		return node, parser.NoChildren
+	}
+	return node, parser.HasChildren
+}
+
+// Continue adds blocks to the shortcode until its closing shortcode
+func (shortcodeBlockParser) Continue(node ast.Node, reader text.Reader, _ parser.Context) parser.State {
+	block := node.(*shortcodeBlock)
+	if block.selfClosing {
+		return parser.Close
+	}
+
+	line, _ := reader.PeekLine()
+	if isClosingShortcode(line, block.name) {
+		reader.AdvanceToEOL()
+		return parser.Close
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+// Close implements parser.BlockParser
+func (shortcodeBlockParser) Close(ast.Node, text.Reader, parser.Context) {}
+
+// CanInterruptParagraph implements parser.BlockParser
+func (shortcodeBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+// CanAcceptIndentedLine implements parser.BlockParser
+func (shortcodeBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// hasClosingShortcode returns true if a line of source closes the shortcode name
+func hasClosingShortcode(source []byte, name string) bool {
+	for len(source) > 0 {
+		line := source
+		if i := bytes.IndexByte(source, '\n'); i >= 0 {
+			line, source = source[:i], source[i+1:]
+		} else {
+			source = nil
+		}
+		if isClosingShortcode(line, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isClosingShortcode returns true if line is the closing shortcode of name
+func isClosingShortcode(line []byte, name string) bool {
+	tag := util.TrimRightSpace(util.TrimLeftSpace(line))
+	code, ok := parseShortcode(tag)
+	return ok && code.closing && code.name == name && len(code.source) == len(tag)
+}
+
+// shortcodeInlineParser parses shortcodes within text
+type shortcodeInlineParser struct{}
+
+// Trigger implements parser.InlineParser
+func (shortcodeInlineParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+// Parse implements parser.InlineParser
+func (shortcodeInlineParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	code, ok := parseShortcode(line)
+	if !ok || code.closing {
+		return nil
+	}
+
+	block.Advance(len(code.source))
+	return &shortcodeInline{shortcode: code}
+}
+
+// shortcodeRenderer renders shortcode nodes with the ShortcodeFunc
+type shortcodeRenderer struct {
+	render   ShortcodeFunc
+	markdown goldmark.Markdown
+}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r *shortcodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindShortcodeBlock, r.renderBlock)
+	reg.Register(kindShortcodeInline, r.renderInline)
+}
+
+// renderBlock renders a shortcode block with its children as the content
+func (r *shortcodeRenderer) renderBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*shortcodeBlock)
+
+	var content bytes.Buffer
+	if block.HasChildren() {
+		if err := r.renderChildren(&content, source, block); err != nil {
+			return ast.WalkStop, err
+		}
+	}
+
+	html, err := r.render(block.name, block.attrs, content.Bytes())
+	if err != nil {
+		// The shortcode stays visible, and so does its content
+		html = []byte("<p>" + escapeHTML(string(block.source)) + "</p>\n")
+		html = append(html, content.Bytes()...)
+	}
+	if _, err := w.Write(html); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// renderChildren renders the children of node, moving them to a document
+// of their own for the duration, so the content is passed to the shortcode
+func (r *shortcodeRenderer) renderChildren(buf *bytes.Buffer, source []byte, node ast.Node) error {
+	doc := ast.NewDocument()
+	for child := node.FirstChild(); child != nil; child = node.FirstChild() {
+		doc.AppendChild(doc, child)
+	}
+	defer func() {
+		for child := doc.FirstChild(); child != nil; child = doc.FirstChild() {
+			node.AppendChild(node, child)
+		}
+	}()
+
+	return r.markdown.Renderer().Render(buf, source, doc)
+}
+
+// renderInline renders a shortcode within text
+func (r *shortcodeRenderer) renderInline(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	inline := node.(*shortcodeInline)
+
+	html, err := r.render(inline.name, inline.attrs, nil)
+	if err != nil {
+		html = []byte(escapeHTML(string(inline.source)))
+	}
+	if _, err := w.Write(html); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
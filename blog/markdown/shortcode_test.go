@@ -0,0 +1,106 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testShortcode renders shortcodes as a tag listing the attributes
+func testShortcode(name string, attrs map[string]string, content []byte) ([]byte, error) {
+	if name == "broken" {
+		return nil, errors.New("broken")
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "<%s", name)
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		fmt.Fprintf(&buf, " %s=%q", key, attrs[key])
+	}
+	fmt.Fprintf(&buf, ">%s</%s>", content, name)
+	return []byte(buf.String()), nil
+}
+
+func TestParseShortcode(t *testing.T) {
+	code, ok := parseShortcode([]byte(`{{< youtube id="a b" start=10 label='Say "hi"' autoplay >}} rest`))
+	if !ok {
+		t.Fatal("expected a shortcode")
+	}
+	if code.name != "youtube" || code.closing || code.selfClosing {
+		t.Errorf("unexpected shortcode %+v", code)
+	}
+	want := map[string]string{"id": "a b", "start": "10", "label": `Say "hi"`, "autoplay": "true"}
+	if !maps.Equal(code.attrs, want) {
+		t.Errorf("unexpected attributes %v", code.attrs)
+	}
+
+	if code, ok := parseShortcode([]byte(`{{< /info-cta >}}`)); !ok || !code.closing || code.name != "info-cta" {
+		t.Errorf("expected a closing shortcode, got %+v", code)
+	}
+	if code, ok := parseShortcode([]byte(`{{<note/>}}`)); !ok || !code.selfClosing || code.name != "note" {
+		t.Errorf("expected a self-closing shortcode, got %+v", code)
+	}
+
+	for _, invalid := range []string{`{{ youtube }}`, `{{< >}}`, `{{< 1a >}}`, `{{< a b="c >}}`} {
+		if _, ok := parseShortcode([]byte(invalid)); ok {
+			t.Errorf("expected %s not to be a shortcode", invalid)
+		}
+	}
+}
+
+func TestShortcodes(t *testing.T) {
+	renderer := NewRenderer(NewShortcodes(testShortcode))
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "block",
+			markdown: "Intro.\n\n{{< youtube id=\"abc\" >}}\n\nOutro.\n",
+			want:     "<p>Intro.</p>\n<youtube id=\"abc\"></youtube><p>Outro.</p>\n",
+		},
+		{
+			name:     "content",
+			markdown: "{{< info-cta >}}\nRead **this**.\n\n- one\n{{< /info-cta >}}\n\nAfter.\n",
+			want:     "<info-cta><p>Read <strong>this</strong>.</p>\n<ul>\n<li>one</li>\n</ul>\n</info-cta><p>After.</p>\n",
+		},
+		{
+			name:     "self-closing",
+			markdown: "{{< info-cta />}}\nText.\n\n{{< /info-cta >}}\n",
+			want:     "<info-cta></info-cta><p>Text.</p>\n<p>{{&lt; /info-cta &gt;}}</p>\n",
+		},
+		{
+			name:     "inline",
+			markdown: "An {{< inline-svg src=\"assets/icons/heart.svg\" >}} icon.\n",
+			want:     "<p>An <inline-svg src=\"assets/icons/heart.svg\"></inline-svg> icon.</p>\n",
+		},
+		{
+			name:     "error",
+			markdown: "{{< broken >}}\n\nSee {{< broken x=\"<b>\" >}}.\n",
+			want:     "<p>{{&lt; broken &gt;}}</p>\n<p>See {{&lt; broken x=&quot;&lt;b&gt;&quot; &gt;}}.</p>\n",
+		},
+		{
+			name:     "code",
+			markdown: "`{{< youtube >}}`\n\n```\n{{< youtube >}}\n```\n",
+			want:     "<p><code>{{&lt; youtube &gt;}}</code></p>\n<pre class=\"chroma\"><code>{{&lt; youtube &gt;}}\n</code></pre>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := string(renderer.Render([]byte(tt.markdown)))
+			if result != tt.want {
+				t.Errorf("unexpected html:\n%s\nwant:\n%s", result, tt.want)
+			}
+		})
+	}
+
+	if renderer.Version() != Version+"+shortcodes/1" {
+		t.Errorf("expected the extension in the version, got %s", renderer.Version())
+	}
+}
@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	chroma "github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// DefaultStyle is the Chroma style of code blocks if the site doesn't configure one
+const DefaultStyle = "monokai"
+
+// DefaultLightStyle is the Chroma style of code blocks in the light
+// appearance if the site doesn't configure one
+const DefaultLightStyle = "github"
+
+// Appearances of the site. The appearance is chosen with the
+// `data-appearance` attribute of the document, and follows the system
+// color scheme if it's unset or `system`.
+const (
+	AppearanceLight = "light"
+	AppearanceDark  = "dark"
+)
+
+// appearanceScopes are the selectors and media queries a stylesheet of an
+// appearance applies in. The selected appearance takes precedence over the
+// system color scheme.
+var appearanceScopes = map[string]struct {
+	selector string
+	media    string
+	system   string
+}{
+	AppearanceLight: {
+		selector: "[data-appearance=light]",
+		media:    "not (prefers-color-scheme: dark)",
+		system:   ":root:not([data-appearance=dark])",
+	},
+	AppearanceDark: {
+		selector: "[data-appearance=dark]",
+		media:    "(prefers-color-scheme: dark)",
+		system:   ":root:not([data-appearance=light])",
+	},
+}
+
+// defaultStyle returns the style highlighted code is formatted with.
+// Code is formatted with classes, so it doesn't depend on the style.
+func defaultStyle() *chroma.Style {
+	return styles.Get(DefaultStyle)
+}
+
+// Stylesheet returns the CSS for highlighted code with the Chroma style
+// name, e.g. `github`, scoped to the appearance. The rules apply when the
+// appearance is selected, or when it's the system color scheme and no
+// other appearance is selected.
+func Stylesheet(name, appearance string) ([]byte, error) {
+	style, ok := styles.Registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown chroma style %q", name)
+	}
+	scope, ok := appearanceScopes[appearance]
+	if !ok {
+		return nil, fmt.Errorf("unknown appearance %q", appearance)
+	}
+
+	var css bytes.Buffer
+	formatter := html.New(html.WithClasses(true), html.WithCSSComments(false))
+	if err := formatter.WriteCSS(&css, style); err != nil {
+		return nil, err
+	}
+
+	// Rules are written one per line, as `selector { declarations }`
+	var rules []string
+	scanner := bufio.NewScanner(&css)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Code blocks are `pre.chroma`, the standalone `.bg` class isn't used
+		if line == "" || strings.HasPrefix(line, ".bg ") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var result bytes.Buffer
+	fmt.Fprintf(&result, "/* Chroma style %s for the %s appearance */\n", name, appearance)
+	for _, rule := range rules {
+		fmt.Fprintf(&result, "%s %s\n", scope.selector, rule)
+	}
+	fmt.Fprintf(&result, "\n@media %s {\n", scope.media)
+	for _, rule := range rules {
+		fmt.Fprintf(&result, "%s %s\n", scope.system, rule)
+	}
+	result.WriteString("}\n")
+
+	return result.Bytes(), nil
+}
@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightClasses(t *testing.T) {
+	result := string(NewRenderer().Render([]byte("```go\nfunc main() {}\n```\n")))
+
+	if !strings.Contains(result, `<span class="kd">func</span>`) {
+		t.Errorf("expected chroma classes, got:\n%s", result)
+	}
+	if strings.Contains(result, "style=") {
+		t.Errorf("expected no inline styles, got:\n%s", result)
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	tests := map[string][]string{
+		AppearanceLight: {
+			"[data-appearance=light] .chroma .k { color: #cf222e }",
+			"@media not (prefers-color-scheme: dark) {\n",
+			":root:not([data-appearance=dark]) .chroma .k { color: #cf222e }",
+		},
+		AppearanceDark: {
+			"[data-appearance=dark] .chroma .k { color: #cf222e }",
+			"@media (prefers-color-scheme: dark) {\n",
+			":root:not([data-appearance=light]) .chroma .k { color: #cf222e }",
+		},
+	}
+	for appearance, rules := range tests {
+		css, err := Stylesheet("github", appearance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rule := range rules {
+			if !strings.Contains(string(css), rule) {
+				t.Errorf("expected %q in the %s stylesheet, got:\n%s", rule, appearance, css)
+			}
+		}
+		if strings.Contains(string(css), ".bg ") {
+			t.Errorf("expected no .bg rule in the %s stylesheet", appearance)
+		}
+	}
+
+	if _, err := Stylesheet("no-such-style", AppearanceDark); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+	if _, err := Stylesheet("github", "sepia"); err == nil {
+		t.Error("expected an error for an unknown appearance")
+	}
+}
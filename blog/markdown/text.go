@@ -0,0 +1,41 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// textMarkdown renders markdown for Text, without highlighting code.
+// Shortcodes are dropped, keeping the markdown between a shortcode and
+// its closing shortcode.
+var textMarkdown = newMarkdown(NewShortcodes(textShortcode))
+
+// textShortcode renders a shortcode as its content
+func textShortcode(_ string, _ map[string]string, content []byte) ([]byte, error) {
+	return content, nil
+}
+
+// Text converts markdown content to plain text, dropping all markup.
+// It's used to build the full-text search index for article bodies.
+func Text(content []byte) string {
+	var buf bytes.Buffer
+	if err := textMarkdown.Convert(content, &buf); err != nil {
+		return ""
+	}
+	tokenizer := html.NewTokenizer(&buf)
+
+	var sb strings.Builder
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(sb.String()), " ")
+		case html.TextToken:
+			sb.Write(tokenizer.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			// Separate block elements so adjacent words don't merge
+			sb.WriteByte(' ')
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// Heading is a heading of a document with the headings nested under it
+type Heading struct {
+	// ID is the id attribute of the heading, unique within the document
+	ID string `json:"id"`
+
+	// Title is the plain text of the heading
+	Title string `json:"title"`
+
+	// Level is the heading level, 1 for h1 to 6 for h6
+	Level int `json:"level"`
+
+	Children []*Heading `json:"children,omitempty"`
+}
+
+// headingAnchorClass is the class of the self-link anchors of headings
+const headingAnchorClass = "heading-anchor"
+
+// htmlTag matches tags of raw HTML in heading text
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// headingIDs generates slugified heading ids, unique within a document.
+// Ids are slugged like tags, so they keep non-ASCII letters.
+type headingIDs struct {
+	values map[string]bool
+}
+
+// newHeadingIDs creates heading ids for a single document
+func newHeadingIDs() parser.IDs {
+	return &headingIDs{
+		values: make(map[string]bool),
+	}
+}
+
+// Generate returns a unique id for the heading text, leaving out raw HTML.
+// Repeated headings get a numbered suffix, e.g. `usage`, `usage-1`.
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	id := model.Slugify(htmlTag.ReplaceAllString(string(value), ""))
+	if id == "" {
+		id = "heading"
+		if kind != ast.KindHeading {
+			id = "id"
+		}
+	}
+
+	result := id
+	for i := 1; s.values[result]; i++ {
+		result = fmt.Sprintf("%s-%d", id, i)
+	}
+	s.values[result] = true
+	return []byte(result)
+}
+
+// Put reserves an id given in the document, like `## Usage {#usage}`
+func (s *headingIDs) Put(value []byte) {
+	s.values[string(value)] = true
+}
+
+// parserContext returns the parser context for a single document
+func parserContext() parser.Context {
+	return parser.NewContext(parser.WithIDs(newHeadingIDs()))
+}
+
+// headingTree returns the headings of a document, nested by level.
+// A heading is nested under the closest preceding heading of a lower level.
+func headingTree(doc ast.Node, source []byte) []*Heading {
+	var (
+		result  []*Heading
+		parents []*Heading
+	)
+	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+		node, ok := child.(*ast.Heading)
+		if !ok {
+			continue
+		}
+
+		heading := &Heading{
+			Title: headingTitle(node, source),
+			Level: node.Level,
+		}
+		if id, ok := node.AttributeString("id"); ok {
+			if id, ok := id.([]byte); ok {
+				heading.ID = string(id)
+			}
+		}
+
+		for len(parents) > 0 && parents[len(parents)-1].Level >= heading.Level {
+			parents = parents[:len(parents)-1]
+		}
+		if len(parents) == 0 {
+			result = append(result, heading)
+		} else {
+			parent := parents[len(parents)-1]
+			parent.Children = append(parent.Children, heading)
+		}
+		parents = append(parents, heading)
+	}
+	return result
+}
+
+// headingTitle returns the plain text of a heading, without markup,
+// raw HTML or the self-link anchor
+func headingTitle(node ast.Node, source []byte) string {
+	var sb strings.Builder
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Link:
+			if isHeadingAnchor(n) {
+				return ast.WalkSkipChildren, nil
+			}
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			sb.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(sb.String())
+}
+
+// HeadingAnchors returns an extension adding a self-link anchor to every
+// heading with an id, e.g. `<a class="heading-anchor" href="#usage">#</a>`
+func HeadingAnchors() Extension {
+	return NewTransform("heading-anchors/1", func(doc *ast.Document, source []byte) {
+		for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+			heading, ok := child.(*ast.Heading)
+			if !ok {
+				continue
+			}
+			id, ok := heading.AttributeString("id")
+			if !ok {
+				continue
+			}
+			value, ok := id.([]byte)
+			if !ok || len(value) == 0 {
+				continue
+			}
+
+			anchor := ast.NewLink()
+			anchor.Destination = append([]byte("#"), value...)
+			anchor.Title = []byte(headingTitle(heading, source))
+			anchor.SetAttributeString("class", []byte(headingAnchorClass))
+			anchor.AppendChild(anchor, ast.NewString([]byte("#")))
+
+			heading.AppendChild(heading, ast.NewString([]byte(" ")))
+			heading.AppendChild(heading, anchor)
+		}
+	})
+}
+
+// isHeadingAnchor returns true if the link is a self-link anchor of a heading
+func isHeadingAnchor(link *ast.Link) bool {
+	class, ok := link.AttributeString("class")
+	if !ok {
+		return false
+	}
+	value, ok := class.([]byte)
+	return ok && string(value) == headingAnchorClass
+}
@@ -0,0 +1,82 @@
+package markdown
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderHeadingIDs(t *testing.T) {
+	renderer := NewRenderer()
+	markdown := []byte("## Usage\n\n## Usage\n\n## Čokolada & kava\n\n## Custom {#usage-2}\n\n## Usage\n\n## ***\n")
+
+	result := string(renderer.Render(markdown))
+
+	for _, want := range []string{
+		`<h2 id="usage">Usage</h2>`,
+		`<h2 id="usage-1">Usage</h2>`,
+		`<h2 id="čokolada-kava">Čokolada &amp; kava</h2>`,
+		`<h2 id="usage-2">Custom</h2>`,
+		`<h2 id="usage-3">Usage</h2>`,
+		`<h2 id="heading">`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %s, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestRenderHeadings(t *testing.T) {
+	renderer := NewRenderer()
+	markdown := []byte("# Title\n\n## Setup \"quoted\"\n\n### Install `go`\n\n#### Deep\n\n## Usage <small>raw</small>\n\n# Appendix\n")
+
+	result, headings := renderer.RenderHeadings(markdown)
+
+	got, err := json.Marshal(headings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"id":"title","title":"Title","level":1,"children":[` +
+		`{"id":"setup-quoted","title":"Setup \"quoted\"","level":2,"children":[` +
+		`{"id":"install-go","title":"Install go","level":3,"children":[` +
+		`{"id":"deep","title":"Deep","level":4}]}]},` +
+		`{"id":"usage-raw","title":"Usage raw","level":2}]},` +
+		`{"id":"appendix","title":"Appendix","level":1}]`
+	if string(got) != want {
+		t.Errorf("unexpected headings:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.Contains(string(result), `<h3 id="install-go">`) {
+		t.Errorf("expected heading ids in the html, got:\n%s", result)
+	}
+
+	// Headings parses without rendering, with the same ids
+	again, err := json.Marshal(renderer.Headings(markdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != want {
+		t.Errorf("expected the same headings, got:\n%s", again)
+	}
+
+	if headings := renderer.Headings([]byte("No headings here.")); len(headings) != 0 {
+		t.Errorf("expected no headings, got %v", headings)
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	renderer := NewRenderer(HeadingAnchors())
+	markdown := []byte("## Usage *now*\n\nText.\n")
+
+	result, headings := renderer.RenderHeadings(markdown)
+
+	want := `<h2 id="usage-now">Usage <em>now</em> <a href="#usage-now" title="Usage now" class="heading-anchor">#</a></h2>`
+	if !strings.Contains(string(result), want) {
+		t.Errorf("expected a self-link anchor, got:\n%s", result)
+	}
+	if len(headings) != 1 || headings[0].Title != "Usage now" {
+		t.Errorf("expected the anchor to be left out of the title, got %+v", headings)
+	}
+	if renderer.Version() != Version+"+heading-anchors/1" {
+		t.Errorf("expected the extension in the version, got %s", renderer.Version())
+	}
+}
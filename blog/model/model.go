@@ -1,13 +1,95 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
 // Metadata represents the YAML front matter of a markdown file
 type Metadata struct {
+	ID          string `yaml:"id"`
 	Title       string `yaml:"title"`
 	Description string `yaml:"description"`
 	OgImage     string `yaml:"ogImage"`
 	Date        string `yaml:"date"`
 	Layout      string `yaml:"layout"`
 	Source      string `yaml:"source"`
+	Tags        Tags   `yaml:"tags"`
+	Draft       bool   `yaml:"draft"`
+	Unlisted    bool   `yaml:"unlisted"`
+	Permalink   string `yaml:"permalink"`
+
+	// TOC disables the table of contents of the article when false
+	TOC *bool `yaml:"toc"`
+
+	// Aliases and RedirectFrom list previous paths of the article
+	Aliases      Paths `yaml:"aliases"`
+	RedirectFrom Paths `yaml:"redirect_from"`
+}
+
+// Tags is a list of tag names. In front matter it may be given
+// as a single string or as a list of strings.
+type Tags []string
+
+// UnmarshalYAML decodes tags from a scalar or a sequence node
+func (t *Tags) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*t = Tags{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		var tags []string
+		if err := value.Decode(&tags); err != nil {
+			return err
+		}
+		*t = tags
+		return nil
+	}
+	return fmt.Errorf("tags: expected a string or a list, got %s", value.Tag)
+}
+
+// Paths is a list of URL paths. In front matter it may be given
+// as a single string or as a list of strings.
+type Paths []string
+
+// UnmarshalYAML decodes paths from a scalar or a sequence node
+func (p *Paths) UnmarshalYAML(value *yaml.Node) error {
+	return (*Tags)(p).UnmarshalYAML(value)
+}
+
+// TagCount is a tag with the number of articles it is assigned to
+type TagCount struct {
+	Tag
+
+	// Count is the number of articles with the tag
+	Count int `db:"count"`
+
+	// URL is the path of the tag page, filled in by the view
+	URL string `db:"-"`
+}
+
+// Slugify converts a name into a lowercase URL path segment,
+// e.g. "Web Components" becomes "web-components".
+func Slugify(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "-")
 }
 
 // ArticleList represents a paginated list of articles
@@ -16,4 +98,98 @@ type ArticleList struct {
 	Total    int       `json:"total"`
 	Page     int       `json:"page"`
 	PageSize int       `json:"pageSize"`
+	Pages    int       `json:"pages"`
+	Links    PageLinks `json:"links"`
+}
+
+// MarshalJSON encodes the list with article summaries, so lists don't
+// carry the content of every article
+func (l ArticleList) MarshalJSON() ([]byte, error) {
+	type list ArticleList
+	return json.Marshal(struct {
+		list
+		Articles []ArticleSummary `json:"articles"`
+	}{list(l), Summaries(l.Articles)})
+}
+
+// ArticleSummary is an article in lists and search results, without the
+// body and table of contents. The single article response has the content.
+type ArticleSummary struct {
+	ID          string
+	Slug        string
+	Title       string
+	Filename    string
+	Description string
+	Date        *time.Time
+	OgImage     string
+	Layout      string
+	Source      string
+	URL         string
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+	Draft       bool
+	Unlisted    bool
+	WordCount   int64
+	ContentHash string
+}
+
+// Summary returns the summary of the article
+func (a *Article) Summary() ArticleSummary {
+	return ArticleSummary{
+		ID:          a.ID,
+		Slug:        a.Slug,
+		Title:       a.Title,
+		Filename:    a.Filename,
+		Description: a.Description,
+		Date:        a.Date,
+		OgImage:     a.OgImage,
+		Layout:      a.Layout,
+		Source:      a.Source,
+		URL:         a.URL,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+		Draft:       a.Draft,
+		Unlisted:    a.Unlisted,
+		WordCount:   a.WordCount,
+		ContentHash: a.ContentHash,
+	}
+}
+
+// Summaries returns the summaries of articles
+func Summaries(articles []Article) []ArticleSummary {
+	result := make([]ArticleSummary, 0, len(articles))
+	for i := range articles {
+		result = append(result, articles[i].Summary())
+	}
+	return result
+}
+
+// PageLinks holds the URLs of neighbouring pages in a paginated list.
+// Links that don't apply, like prev on the first page, are empty.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// SearchResult is an article matched by full-text search
+type SearchResult struct {
+	Article
+
+	// Snippet is an HTML excerpt of the best matching column, with matches wrapped in <mark>
+	Snippet string `db:"snippet"`
+
+	// Rank is the bm25 score of the match, lower is more relevant
+	Rank float64 `db:"rank"`
+}
+
+// MarshalJSON encodes the search result with the article summary
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ArticleSummary
+		Snippet string
+		Rank    float64
+	}{r.Article.Summary(), r.Snippet, r.Rank})
 }
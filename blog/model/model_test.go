@@ -1 +1,36 @@
 package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func TestMetadataTags(t *testing.T) {
+	var meta model.Metadata
+
+	require.NoError(t, yaml.Unmarshal([]byte("tags: posts"), &meta))
+	require.Equal(t, model.Tags{"posts"}, meta.Tags)
+
+	require.NoError(t, yaml.Unmarshal([]byte("tags: [css, layout]"), &meta))
+	require.Equal(t, model.Tags{"css", "layout"}, meta.Tags)
+
+	require.Error(t, yaml.Unmarshal([]byte("tags: {a: b}"), &meta))
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"CSS":                "css",
+		"Web Components":     "web-components",
+		"  scroll--driven! ": "scroll-driven",
+		"Čokolada & kava":    "čokolada-kava",
+		"!!!":                "",
+	}
+
+	for input, want := range tests {
+		require.Equal(t, want, model.Slugify(input), input)
+	}
+}
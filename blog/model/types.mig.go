@@ -122,6 +122,9 @@ type Migrations struct {
 
 	// Status
 	Status string `db:"status"`
+
+	// Checksum
+	Checksum string `db:"checksum"`
 }
 
 // GetProject will return the value of Project.
@@ -136,14 +139,17 @@ func (m *Migrations) GetStatementIndex() int64 { return m.StatementIndex }
 // GetStatus will return the value of Status.
 func (m *Migrations) GetStatus() string { return m.Status }
 
+// GetChecksum will return the value of Checksum.
+func (m *Migrations) GetChecksum() string { return m.Checksum }
+
 // MigrationsTable is the name of the table in the DB.
 const MigrationsTable = "`migrations`"
 
 // MigrationsFields is a list of all columns in the DB table.
-var MigrationsFields = []string{"project", "filename", "statement_index", "status"}
+var MigrationsFields = []string{"project", "filename", "statement_index", "status", "checksum"}
 
 // MigrationsPrimaryFields are the primary key fields in the DB table.
-var MigrationsPrimaryFields = []string{"project"}
+var MigrationsPrimaryFields = []string{"project", "filename"}
 
 // Article generated for db table `article`.
 type Article struct {
@@ -182,6 +188,27 @@ type Article struct {
 
 	// Updated At
 	UpdatedAt *time.Time `db:"updated_at"`
+
+	// Draft
+	Draft bool `db:"draft"`
+
+	// Unlisted
+	Unlisted bool `db:"unlisted"`
+
+	// Body Markdown
+	BodyMarkdown string `db:"body_markdown"`
+
+	// Body HTML
+	BodyHTML string `db:"body_html"`
+
+	// Word Count
+	WordCount int64 `db:"word_count"`
+
+	// Content Hash
+	ContentHash string `db:"content_hash"`
+
+	// TOC
+	TOC string `db:"toc"`
 }
 
 // GetID will return the value of ID.
@@ -229,15 +256,195 @@ func (a *Article) GetUpdatedAt() *time.Time { return a.UpdatedAt }
 // SetUpdatedAt sets UpdatedAt to the provided value.
 func (a *Article) SetUpdatedAt(stamp time.Time) { a.UpdatedAt = &stamp }
 
+// GetDraft will return the value of Draft.
+func (a *Article) GetDraft() bool { return a.Draft }
+
+// GetUnlisted will return the value of Unlisted.
+func (a *Article) GetUnlisted() bool { return a.Unlisted }
+
+// GetBodyMarkdown will return the value of BodyMarkdown.
+func (a *Article) GetBodyMarkdown() string { return a.BodyMarkdown }
+
+// GetBodyHTML will return the value of BodyHTML.
+func (a *Article) GetBodyHTML() string { return a.BodyHTML }
+
+// GetWordCount will return the value of WordCount.
+func (a *Article) GetWordCount() int64 { return a.WordCount }
+
+// GetContentHash will return the value of ContentHash.
+func (a *Article) GetContentHash() string { return a.ContentHash }
+
+// GetTOC will return the value of TOC.
+func (a *Article) GetTOC() string { return a.TOC }
+
 // ArticleTable is the name of the table in the DB.
 const ArticleTable = "`article`"
 
 // ArticleFields is a list of all columns in the DB table.
-var ArticleFields = []string{"id", "slug", "title", "filename", "description", "date", "og_image", "layout", "source", "url", "created_at", "updated_at"}
+var ArticleFields = []string{"id", "slug", "title", "filename", "description", "date", "og_image", "layout", "source", "url", "created_at", "updated_at", "draft", "unlisted", "body_markdown", "body_html", "word_count", "content_hash", "toc"}
 
 // ArticlePrimaryFields are the primary key fields in the DB table.
 var ArticlePrimaryFields = []string{"id"}
 
+// Tag generated for db table `tag`.
+type Tag struct {
+	// Slug
+	Slug string `db:"slug"`
+
+	// Name
+	Name string `db:"name"`
+}
+
+// GetSlug will return the value of Slug.
+func (t *Tag) GetSlug() string { return t.Slug }
+
+// GetName will return the value of Name.
+func (t *Tag) GetName() string { return t.Name }
+
+// TagTable is the name of the table in the DB.
+const TagTable = "`tag`"
+
+// TagFields is a list of all columns in the DB table.
+var TagFields = []string{"slug", "name"}
+
+// TagPrimaryFields are the primary key fields in the DB table.
+var TagPrimaryFields = []string{"slug"}
+
+// ArticleTag generated for db table `article_tag`.
+type ArticleTag struct {
+	// Article ID
+	ArticleID string `db:"article_id"`
+
+	// Tag
+	Tag string `db:"tag"`
+}
+
+// GetArticleID will return the value of ArticleID.
+func (a *ArticleTag) GetArticleID() string { return a.ArticleID }
+
+// GetTag will return the value of Tag.
+func (a *ArticleTag) GetTag() string { return a.Tag }
+
+// ArticleTagTable is the name of the table in the DB.
+const ArticleTagTable = "`article_tag`"
+
+// ArticleTagFields is a list of all columns in the DB table.
+var ArticleTagFields = []string{"article_id", "tag"}
+
+// ArticleTagPrimaryFields are the primary key fields in the DB table.
+var ArticleTagPrimaryFields = []string{"article_id", "tag"}
+
+// RenderCache generated for db table `render_cache`.
+type RenderCache struct {
+	// Content hash
+	Hash string `db:"hash"`
+
+	// Rendered HTML
+	HTML string `db:"html"`
+
+	// Created At
+	CreatedAt *time.Time `db:"created_at"`
+
+	// TOC
+	TOC string `db:"toc"`
+}
+
+// GetHash will return the value of Hash.
+func (r *RenderCache) GetHash() string { return r.Hash }
+
+// GetHTML will return the value of HTML.
+func (r *RenderCache) GetHTML() string { return r.HTML }
+
+// GetCreatedAt will return the value of CreatedAt.
+func (r *RenderCache) GetCreatedAt() *time.Time { return r.CreatedAt }
+
+// SetCreatedAt sets CreatedAt to the provided value.
+func (r *RenderCache) SetCreatedAt(stamp time.Time) { r.CreatedAt = &stamp }
+
+// GetTOC will return the value of TOC.
+func (r *RenderCache) GetTOC() string { return r.TOC }
+
+// RenderCacheTable is the name of the table in the DB.
+const RenderCacheTable = "`render_cache`"
+
+// RenderCacheFields is a list of all columns in the DB table.
+var RenderCacheFields = []string{"hash", "html", "created_at", "toc"}
+
+// RenderCachePrimaryFields are the primary key fields in the DB table.
+var RenderCachePrimaryFields = []string{"hash"}
+
+// ArticleSlug generated for db table `article_slug`.
+type ArticleSlug struct {
+	// Previous slug
+	Slug string `db:"slug"`
+
+	// Article ID
+	ArticleID string `db:"article_id"`
+
+	// Created At
+	CreatedAt *time.Time `db:"created_at"`
+}
+
+// GetSlug will return the value of Slug.
+func (a *ArticleSlug) GetSlug() string { return a.Slug }
+
+// GetArticleID will return the value of ArticleID.
+func (a *ArticleSlug) GetArticleID() string { return a.ArticleID }
+
+// GetCreatedAt will return the value of CreatedAt.
+func (a *ArticleSlug) GetCreatedAt() *time.Time { return a.CreatedAt }
+
+// SetCreatedAt sets CreatedAt to the provided value.
+func (a *ArticleSlug) SetCreatedAt(stamp time.Time) { a.CreatedAt = &stamp }
+
+// ArticleSlugTable is the name of the table in the DB.
+const ArticleSlugTable = "`article_slug`"
+
+// ArticleSlugFields is a list of all columns in the DB table.
+var ArticleSlugFields = []string{"slug", "article_id", "created_at"}
+
+// ArticleSlugPrimaryFields are the primary key fields in the DB table.
+var ArticleSlugPrimaryFields = []string{"slug"}
+
+// Redirect generated for db table `redirect`.
+type Redirect struct {
+	// Path
+	Path string `db:"path"`
+
+	// Target
+	Target string `db:"target"`
+
+	// Article ID, empty for site redirects
+	ArticleID string `db:"article_id"`
+
+	// Created At
+	CreatedAt *time.Time `db:"created_at"`
+}
+
+// GetPath will return the value of Path.
+func (r *Redirect) GetPath() string { return r.Path }
+
+// GetTarget will return the value of Target.
+func (r *Redirect) GetTarget() string { return r.Target }
+
+// GetArticleID will return the value of ArticleID.
+func (r *Redirect) GetArticleID() string { return r.ArticleID }
+
+// GetCreatedAt will return the value of CreatedAt.
+func (r *Redirect) GetCreatedAt() *time.Time { return r.CreatedAt }
+
+// SetCreatedAt sets CreatedAt to the provided value.
+func (r *Redirect) SetCreatedAt(stamp time.Time) { r.CreatedAt = &stamp }
+
+// RedirectTable is the name of the table in the DB.
+const RedirectTable = "`redirect`"
+
+// RedirectFields is a list of all columns in the DB table.
+var RedirectFields = []string{"path", "target", "article_id", "created_at"}
+
+// RedirectPrimaryFields are the primary key fields in the DB table.
+var RedirectPrimaryFields = []string{"path"}
+
 func (m *Migrations) Insert(opts ...QueryOption) string {
 	cfg := (&QueryConfig{Table: MigrationsTable, Statement: "INSERT INTO"}).Apply(opts...)
 	cols := MigrationsFields
@@ -351,3 +558,288 @@ func (a *Article) Delete(opts ...QueryOption) string {
 	}
 	return query
 }
+
+func (t *Tag) Insert(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: TagTable, Statement: "INSERT INTO"}).Apply(opts...)
+	cols := TagFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	return fmt.Sprintf("%s %s (%s) VALUES (:%s)", cfg.Statement, cfg.Table, strings.Join(cols, ", "), strings.Join(cols, ", :"))
+}
+
+func (t *Tag) Select(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: TagTable}).Apply(opts...)
+	cols := "*"
+	if len(cfg.Columns) > 0 {
+		cols = strings.Join(cfg.Columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", cols, cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	if cfg.OrderBy != "" {
+		query += " ORDER BY " + cfg.OrderBy
+	}
+	if cfg.LimitOffset > 0 {
+		query += fmt.Sprintf(" LIMIT %d, %d", cfg.LimitStart, cfg.LimitOffset)
+	}
+	return query
+}
+
+func (t *Tag) Update(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: TagTable}).Apply(opts...)
+	cols := TagFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	setClause := ""
+	for i, col := range cols {
+		if i > 0 {
+			setClause += ", "
+		}
+		setClause += col + "=:" + col
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s", cfg.Table, setClause)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (t *Tag) Delete(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: TagTable}).Apply(opts...)
+	query := fmt.Sprintf("DELETE FROM %s", cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (a *ArticleTag) Insert(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleTagTable, Statement: "INSERT INTO"}).Apply(opts...)
+	cols := ArticleTagFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	return fmt.Sprintf("%s %s (%s) VALUES (:%s)", cfg.Statement, cfg.Table, strings.Join(cols, ", "), strings.Join(cols, ", :"))
+}
+
+func (a *ArticleTag) Select(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleTagTable}).Apply(opts...)
+	cols := "*"
+	if len(cfg.Columns) > 0 {
+		cols = strings.Join(cfg.Columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", cols, cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	if cfg.OrderBy != "" {
+		query += " ORDER BY " + cfg.OrderBy
+	}
+	if cfg.LimitOffset > 0 {
+		query += fmt.Sprintf(" LIMIT %d, %d", cfg.LimitStart, cfg.LimitOffset)
+	}
+	return query
+}
+
+func (a *ArticleTag) Update(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleTagTable}).Apply(opts...)
+	cols := ArticleTagFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	setClause := ""
+	for i, col := range cols {
+		if i > 0 {
+			setClause += ", "
+		}
+		setClause += col + "=:" + col
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s", cfg.Table, setClause)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (a *ArticleTag) Delete(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleTagTable}).Apply(opts...)
+	query := fmt.Sprintf("DELETE FROM %s", cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (r *RenderCache) Insert(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RenderCacheTable, Statement: "INSERT INTO"}).Apply(opts...)
+	cols := RenderCacheFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	return fmt.Sprintf("%s %s (%s) VALUES (:%s)", cfg.Statement, cfg.Table, strings.Join(cols, ", "), strings.Join(cols, ", :"))
+}
+
+func (r *RenderCache) Select(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RenderCacheTable}).Apply(opts...)
+	cols := "*"
+	if len(cfg.Columns) > 0 {
+		cols = strings.Join(cfg.Columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", cols, cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	if cfg.OrderBy != "" {
+		query += " ORDER BY " + cfg.OrderBy
+	}
+	if cfg.LimitOffset > 0 {
+		query += fmt.Sprintf(" LIMIT %d, %d", cfg.LimitStart, cfg.LimitOffset)
+	}
+	return query
+}
+
+func (r *RenderCache) Update(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RenderCacheTable}).Apply(opts...)
+	cols := RenderCacheFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	setClause := ""
+	for i, col := range cols {
+		if i > 0 {
+			setClause += ", "
+		}
+		setClause += col + "=:" + col
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s", cfg.Table, setClause)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (r *RenderCache) Delete(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RenderCacheTable}).Apply(opts...)
+	query := fmt.Sprintf("DELETE FROM %s", cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (a *ArticleSlug) Insert(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleSlugTable, Statement: "INSERT INTO"}).Apply(opts...)
+	cols := ArticleSlugFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	return fmt.Sprintf("%s %s (%s) VALUES (:%s)", cfg.Statement, cfg.Table, strings.Join(cols, ", "), strings.Join(cols, ", :"))
+}
+
+func (a *ArticleSlug) Select(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleSlugTable}).Apply(opts...)
+	cols := "*"
+	if len(cfg.Columns) > 0 {
+		cols = strings.Join(cfg.Columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", cols, cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	if cfg.OrderBy != "" {
+		query += " ORDER BY " + cfg.OrderBy
+	}
+	if cfg.LimitOffset > 0 {
+		query += fmt.Sprintf(" LIMIT %d, %d", cfg.LimitStart, cfg.LimitOffset)
+	}
+	return query
+}
+
+func (a *ArticleSlug) Update(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleSlugTable}).Apply(opts...)
+	cols := ArticleSlugFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	setClause := ""
+	for i, col := range cols {
+		if i > 0 {
+			setClause += ", "
+		}
+		setClause += col + "=:" + col
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s", cfg.Table, setClause)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (a *ArticleSlug) Delete(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: ArticleSlugTable}).Apply(opts...)
+	query := fmt.Sprintf("DELETE FROM %s", cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (r *Redirect) Insert(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RedirectTable, Statement: "INSERT INTO"}).Apply(opts...)
+	cols := RedirectFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	return fmt.Sprintf("%s %s (%s) VALUES (:%s)", cfg.Statement, cfg.Table, strings.Join(cols, ", "), strings.Join(cols, ", :"))
+}
+
+func (r *Redirect) Select(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RedirectTable}).Apply(opts...)
+	cols := "*"
+	if len(cfg.Columns) > 0 {
+		cols = strings.Join(cfg.Columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", cols, cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	if cfg.OrderBy != "" {
+		query += " ORDER BY " + cfg.OrderBy
+	}
+	if cfg.LimitOffset > 0 {
+		query += fmt.Sprintf(" LIMIT %d, %d", cfg.LimitStart, cfg.LimitOffset)
+	}
+	return query
+}
+
+func (r *Redirect) Update(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RedirectTable}).Apply(opts...)
+	cols := RedirectFields
+	if len(cfg.Columns) > 0 {
+		cols = cfg.Columns
+	}
+	setClause := ""
+	for i, col := range cols {
+		if i > 0 {
+			setClause += ", "
+		}
+		setClause += col + "=:" + col
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s", cfg.Table, setClause)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
+
+func (r *Redirect) Delete(opts ...QueryOption) string {
+	cfg := (&QueryConfig{Table: RedirectTable}).Apply(opts...)
+	query := fmt.Sprintf("DELETE FROM %s", cfg.Table)
+	if cfg.Where != "" {
+		query += " WHERE " + cfg.Where
+	}
+	return query
+}
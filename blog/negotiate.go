@@ -0,0 +1,76 @@
+package blog
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types served by the content negotiated routes
+const (
+	mediaHTML     = "text/html"
+	mediaJSON     = "application/json"
+	mediaAtom     = "application/atom+xml"
+	mediaMarkdown = "text/markdown"
+)
+
+// negotiate picks the offer best matching the Accept header of the request.
+//
+// Each offer takes the quality of the most specific media range matching
+// it, so `text/html;q=0, */*` excludes HTML. Offers are listed in order of
+// preference, which breaks ties; the first offer is returned when the
+// request has no Accept header or nothing is acceptable, so browsers and
+// crawlers with unusual headers still get the default.
+// Responses that depend on negotiation must set `Vary: Accept`.
+func negotiate(r *http.Request, offers ...string) string {
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, quality})
+	}
+
+	best, bestQuality := offers[0], 0.0
+	for _, offer := range offers {
+		quality, specificity := 0.0, -1
+		for _, mr := range ranges {
+			if s := mediaMatch(mr.mediaType, offer); s > specificity {
+				quality, specificity = mr.quality, s
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+
+	return best
+}
+
+// mediaMatch returns how specifically the media range matches the offer:
+// 2 for an exact match, 1 for `type/*`, 0 for `*/*` and -1 for no match.
+func mediaMatch(mediaRange, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
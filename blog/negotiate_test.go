@@ -0,0 +1,100 @@
+package blog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{mediaHTML, mediaJSON, mediaMarkdown}
+
+	for accept, want := range map[string]string{
+		"":                                  mediaHTML,
+		"*/*":                               mediaHTML,
+		"application/json":                  mediaJSON,
+		"text/markdown":                     mediaMarkdown,
+		"text/*":                            mediaHTML,
+		"image/png":                         mediaHTML,
+		"application/json;q=0.5, text/html": mediaHTML,
+		"text/html;q=0.5, application/json": mediaJSON,
+		"text/markdown, */*;q=0.1":          mediaMarkdown,
+		"text/html;q=0, */*":                mediaJSON,
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": mediaHTML,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		require.Equal(t, want, negotiate(r, offers...), accept)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	writeArticle(t, filepath.Join(dataDir, "hello.md"), "Hello", time.Now())
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	h := &Handlers{repository: m.repository}
+
+	router := chi.NewRouter()
+	router.Get("/blog/", h.ListArticlesHTML)
+	router.Get("/blog/{slug}", h.GetArticleHTML)
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept", accept)
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("article as JSON", func(t *testing.T) {
+		w := get("/blog/hello", "application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, "Accept", w.Header().Get("Vary"))
+
+		var article model.Article
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&article))
+		require.Equal(t, "Hello", article.Title)
+	})
+
+	t.Run("article as markdown", func(t *testing.T) {
+		w := get("/blog/hello", "text/markdown")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+
+		require.Equal(t, "Body of Hello\n", w.Body.String())
+	})
+
+	t.Run("missing article", func(t *testing.T) {
+		w := get("/blog/missing", "application/json")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "Accept", w.Header().Get("Vary"))
+	})
+
+	t.Run("list as JSON", func(t *testing.T) {
+		w := get("/blog/", "application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "Accept", w.Header().Get("Vary"))
+
+		var list model.ArticleList
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+		require.Equal(t, 1, list.Total)
+		require.Equal(t, "/blog/", list.Links.Self)
+	})
+}
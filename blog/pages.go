@@ -0,0 +1,236 @@
+package blog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/platform-example/blog/view"
+)
+
+// themePage is a page template of the theme and the path it's served at
+type themePage struct {
+	// Template is the template path in the theme, e.g. `pages/about.vuego`
+	Template string
+
+	// Path is the URL path of the page
+	Path string
+
+	// Sitemap is false for pages left out of the sitemap with `sitemap: false`
+	Sitemap bool
+}
+
+// Output returns the file the page is generated to, relative to the output directory
+func (p themePage) Output() string {
+	return outputFile(p.Path)
+}
+
+// reservedPages are rendered with article data by dedicated handlers,
+// so they aren't part of the routing table
+var reservedPages = map[string]bool{
+	"pages/index.vuego":      true,
+	"pages/blog.vuego":       true,
+	"pages/blog/index.vuego": true,
+}
+
+// themePages returns the routing table for the pages in the `pages/`
+// directory of the theme, ordered by path. A page is served at the
+// `permalink:` of its front matter, `pages/docs/index.vuego` at `/docs/`
+// and other pages like `pages/about.vuego` at `/about.html`. Two pages
+// with the same path, or a page at a route of the module, are an error.
+func themePages(fsys fs.FS) ([]themePage, error) {
+	claims := newPermalinks()
+
+	var result []themePage
+	err := fs.WalkDir(fsys, "pages", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".vuego" || reservedPages[name] {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		page, err := readPage(name, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := claims.Claim(page.Path, page.Template); err != nil {
+			return err
+		}
+
+		result = append(result, page)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// readPage reads the path of the page template name with contents data.
+// The permalink may use `{{slug}}`, the file name without extension.
+func readPage(name string, data []byte) (themePage, error) {
+	var meta struct {
+		Permalink string `yaml:"permalink"`
+		Sitemap   *bool  `yaml:"sitemap"`
+	}
+	if content := string(data); strings.HasPrefix(content, "---") {
+		if parts := strings.SplitN(content, "---", 3); len(parts) == 3 {
+			if err := yaml.Unmarshal([]byte(parts[1]), &meta); err != nil {
+				return themePage{}, err
+			}
+		}
+	}
+
+	page := themePage{
+		Template: name,
+		Sitemap:  meta.Sitemap == nil || *meta.Sitemap,
+	}
+
+	rel := strings.TrimSuffix(strings.TrimPrefix(name, "pages/"), ".vuego")
+	switch {
+	case meta.Permalink != "":
+		urlPath, err := expandPermalink(meta.Permalink, map[string]string{"slug": path.Base(rel)})
+		if err != nil {
+			return themePage{}, err
+		}
+		page.Path = urlPath
+	case rel == "index":
+		page.Path = "/"
+	case path.Base(rel) == "index":
+		page.Path = "/" + path.Dir(rel) + "/"
+	default:
+		page.Path = "/" + rel + ".html"
+	}
+	return page, nil
+}
+
+// pageRoutes is the routing table of theme pages, built again when the theme changes
+type pageRoutes struct {
+	theme *themeVersion
+
+	mu      sync.Mutex
+	version string
+	pages   map[string]themePage
+}
+
+// Lookup returns the page served at path. A page at `/docs/` is also found for `/docs`.
+func (p *pageRoutes) Lookup(urlPath string) (themePage, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return themePage{}, false, err
+	}
+
+	page, ok := p.pages[urlPath]
+	if !ok && !strings.HasSuffix(urlPath, "/") {
+		page, ok = p.pages[urlPath+"/"]
+	}
+	return page, ok, nil
+}
+
+// Pages returns the pages of the routing table, ordered by path
+func (p *pageRoutes) Pages() ([]themePage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	result := make([]themePage, 0, len(p.pages))
+	for _, page := range p.pages {
+		result = append(result, page)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// load builds the routing table if the theme changed since it was built
+func (p *pageRoutes) load() error {
+	version := p.theme.Get()
+	if p.pages != nil && version == p.version {
+		return nil
+	}
+
+	pages, err := themePages(p.theme.fs)
+	if err != nil {
+		return err
+	}
+	p.pages = make(map[string]themePage, len(pages))
+	for _, page := range pages {
+		p.pages[page.Path] = page
+	}
+	p.version = version
+	return nil
+}
+
+// NotFound handles requests that don't match a route. It serves theme
+// pages and articles at their permalinks, redirects redirected paths,
+// and replies with the 404 page of the theme otherwise.
+func (h *Handlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	if h.servePage(w, r) || h.serveArticle(w, r) {
+		return
+	}
+
+	redirect, err := h.repository.GetRedirect(r.Context(), r.URL.Path)
+	if err != nil {
+		h.notFound(w, r, "")
+		return
+	}
+
+	target := redirect.Target
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
+// servePage renders a theme page. It returns false if there's no page at the request path.
+func (h *Handlers) servePage(w http.ResponseWriter, r *http.Request) bool {
+	if h.routes == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	page, ok, err := h.routes.Lookup(r.URL.Path)
+	if err != nil {
+		h.serverError(w, r, err)
+		return true
+	}
+	if !ok {
+		return false
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if notModified(w, r, validator{ETag: h.etag("page", page.Template, page.Path)}) {
+		return true
+	}
+
+	h.writeRendered(w, r, "text/html; charset=utf-8", func(w io.Writer) error {
+		return h.renderPage(r.Context(), w, page)
+	})
+	return true
+}
+
+// renderPage renders a theme page with the data shared by the server and the generator
+func (h *Handlers) renderPage(ctx context.Context, w io.Writer, page themePage) error {
+	return h.views.Page(ctx, w, page.Template, &view.PageData{URL: page.Path})
+}
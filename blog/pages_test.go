@@ -0,0 +1,90 @@
+package blog
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemePages(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/index.vuego":       {Data: []byte("<h1>Home</h1>")},
+		"pages/blog.vuego":        {Data: []byte("<h1>Blog</h1>")},
+		"pages/blog/index.vuego":  {Data: []byte("<h1>Blog</h1>")},
+		"pages/404.vuego":         {Data: []byte("---\ntitle: Not Found\nsitemap: false\n---\n<h1>404</h1>")},
+		"pages/about.vuego":       {Data: []byte("---\npermalink: /about/\n---\n<h1>About</h1>")},
+		"pages/docs/index.vuego":  {Data: []byte("<h1>Docs</h1>")},
+		"pages/docs/setup.vuego":  {Data: []byte("<h1>Setup</h1>")},
+		"pages/docs/readme.webc":  {Data: []byte("<h1>Skipped</h1>")},
+		"layouts/base.vuego":      {Data: []byte("<html></html>")},
+		"pages/feed.vuego":        {Data: []byte("---\npermalink: rss.xml\n---\n<rss></rss>")},
+		"pages/legal/terms.vuego": {Data: []byte("---\npermalink: /legal/{{ slug }}/\n---\n<h1>Terms</h1>")},
+	}
+
+	pages, err := themePages(fsys)
+	require.NoError(t, err)
+	require.Equal(t, []themePage{
+		{Template: "pages/404.vuego", Path: "/404.html"},
+		{Template: "pages/about.vuego", Path: "/about/", Sitemap: true},
+		{Template: "pages/docs/index.vuego", Path: "/docs/", Sitemap: true},
+		{Template: "pages/docs/setup.vuego", Path: "/docs/setup.html", Sitemap: true},
+		{Template: "pages/legal/terms.vuego", Path: "/legal/terms/", Sitemap: true},
+		{Template: "pages/feed.vuego", Path: "/rss.xml", Sitemap: true},
+	}, pages)
+
+	require.Equal(t, "404.html", pages[0].Output())
+	require.Equal(t, "about/index.html", pages[1].Output())
+
+	t.Run("duplicate paths", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"pages/about.vuego":  {Data: []byte("<h1>About</h1>")},
+			"pages/resume.vuego": {Data: []byte("---\npermalink: /about.html\n---\n<h1>Resume</h1>")},
+		}
+		_, err := themePages(fsys)
+		require.ErrorContains(t, err, "both served at /about.html")
+
+		fsys = fstest.MapFS{
+			"pages/about.vuego":       {Data: []byte("---\npermalink: /about\n---\n<h1>About</h1>")},
+			"pages/about/index.vuego": {Data: []byte("<h1>About</h1>")},
+		}
+		_, err = themePages(fsys)
+		require.ErrorContains(t, err, "pages/about/index.vuego and pages/about.vuego are both served")
+	})
+
+	t.Run("reserved paths", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"pages/home.vuego": {Data: []byte("---\npermalink: /\n---\n<h1>Home</h1>")},
+		}
+		_, err := themePages(fsys)
+		require.ErrorContains(t, err, "route / and pages/home.vuego are both served at /")
+
+		fsys = fstest.MapFS{
+			"pages/feed.vuego": {Data: []byte("---\npermalink: /feed.xml\n---\n<rss></rss>")},
+		}
+		_, err = themePages(fsys)
+		require.ErrorContains(t, err, "route /feed.xml")
+	})
+}
+
+func TestPageRoutes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/about.vuego":      {Data: []byte("---\npermalink: /about/\n---\n<h1>About</h1>")},
+		"pages/docs/setup.vuego": {Data: []byte("<h1>Setup</h1>")},
+	}
+	routes := &pageRoutes{theme: newThemeVersion(fsys)}
+
+	tests := map[string]string{
+		"/about/":          "pages/about.vuego",
+		"/about":           "pages/about.vuego",
+		"/docs/setup.html": "pages/docs/setup.vuego",
+		"/docs/setup":      "",
+		"/about.html":      "",
+	}
+	for urlPath, template := range tests {
+		page, ok, err := routes.Lookup(urlPath)
+		require.NoError(t, err)
+		require.Equal(t, template != "", ok, urlPath)
+		require.Equal(t, template, page.Template, urlPath)
+	}
+}
@@ -0,0 +1,181 @@
+package blog
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/platform-example/blog/model"
+	"github.com/titpetric/platform-example/blog/storage"
+)
+
+const (
+	// defaultPageSize is the page size of article lists when pageSize isn't given
+	defaultPageSize = 20
+
+	// maxPageSize is the largest page size accepted from a request
+	maxPageSize = 100
+)
+
+// pagination describes a single page of a list
+type pagination struct {
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// Pages returns the number of pages, an empty list has a single empty page
+func (p pagination) Pages() int {
+	if p.Total <= 0 {
+		return 1
+	}
+	return (p.Total + p.PageSize - 1) / p.PageSize
+}
+
+// Offset returns the index of the first item on the page
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// Links returns the links to neighbouring pages, pageURL builds the URL of a page
+func (p pagination) Links(pageURL func(page int) string) model.PageLinks {
+	pages := p.Pages()
+	links := model.PageLinks{
+		Self:  pageURL(p.Page),
+		First: pageURL(1),
+		Last:  pageURL(pages),
+	}
+	if p.Page > 1 && p.Page <= pages {
+		links.Prev = pageURL(p.Page - 1)
+	}
+	if p.Page < pages {
+		links.Next = pageURL(p.Page + 1)
+	}
+	return links
+}
+
+// List creates an ArticleList for the page
+func (p pagination) List(articles []model.Article, pageURL func(page int) string) *model.ArticleList {
+	if articles == nil {
+		articles = []model.Article{}
+	}
+	return &model.ArticleList{
+		Articles: articles,
+		Total:    p.Total,
+		Page:     p.Page,
+		PageSize: p.PageSize,
+		Pages:    p.Pages(),
+		Links:    p.Links(pageURL),
+	}
+}
+
+// parsePagination reads the `page` and `pageSize` query parameters
+func parsePagination(query url.Values) (pagination, error) {
+	result := pagination{
+		Page:     1,
+		PageSize: defaultPageSize,
+	}
+
+	if value := query.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return result, fmt.Errorf("invalid page %q", value)
+		}
+		result.Page = page
+	}
+
+	if value := query.Get("pageSize"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return result, fmt.Errorf("invalid pageSize %q, expected 1-%d", value, maxPageSize)
+		}
+		result.PageSize = pageSize
+	}
+
+	return result, nil
+}
+
+// parseArticleFilter reads the `year`, `month` and `source` query parameters
+func parseArticleFilter(query url.Values) (storage.ArticleFilter, error) {
+	var filter storage.ArticleFilter
+
+	if value := query.Get("year"); value != "" {
+		year, err := strconv.Atoi(value)
+		if err != nil || year < 1 {
+			return filter, fmt.Errorf("invalid year %q", value)
+		}
+		filter.Year = year
+	}
+
+	if value := query.Get("month"); value != "" {
+		month, err := strconv.Atoi(value)
+		if err != nil || month < 1 || month > 12 {
+			return filter, fmt.Errorf("invalid month %q", value)
+		}
+		if filter.Year == 0 {
+			return filter, fmt.Errorf("month requires a year")
+		}
+		filter.Month = month
+	}
+
+	switch source := query.Get("source"); source {
+	case "", storage.SourceLocal, storage.SourceExternal:
+		filter.Source = source
+	default:
+		return filter, fmt.Errorf("invalid source %q, expected %q or %q", source, storage.SourceLocal, storage.SourceExternal)
+	}
+
+	return filter, nil
+}
+
+// queryPageURL returns a function building page URLs for path, keeping the
+// query parameters. The page is set as a query parameter.
+func queryPageURL(path string, query url.Values) func(page int) string {
+	return func(page int) string {
+		values := url.Values{}
+		for k, v := range query {
+			values[k] = v
+		}
+		values.Set("page", strconv.Itoa(page))
+		return path + "?" + values.Encode()
+	}
+}
+
+// blogPageURL returns a function building URLs of the paginated blog list,
+// `/blog/` for the first page and `/blog/page/{n}/` for the rest.
+func blogPageURL(query url.Values) func(page int) string {
+	values := url.Values{}
+	for k, v := range query {
+		if k != "page" && k != "pageSize" {
+			values[k] = v
+		}
+	}
+	suffix := ""
+	if len(values) > 0 {
+		suffix = "?" + values.Encode()
+	}
+
+	return func(page int) string {
+		if page <= 1 {
+			return "/blog/" + suffix
+		}
+		return "/blog/page/" + strconv.Itoa(page) + "/" + suffix
+	}
+}
+
+// linkHeader formats links as a RFC 8288 Link header value
+func linkHeader(links model.PageLinks) string {
+	var result []string
+	for _, link := range []struct{ rel, url string }{
+		{"first", links.First},
+		{"prev", links.Prev},
+		{"next", links.Next},
+		{"last", links.Last},
+	} {
+		if link.url != "" {
+			result = append(result, fmt.Sprintf("<%s>; rel=%q", link.url, link.rel))
+		}
+	}
+	return strings.Join(result, ", ")
+}
@@ -0,0 +1,147 @@
+package blog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+	"github.com/titpetric/platform-example/blog/storage"
+)
+
+func TestPagination(t *testing.T) {
+	page := pagination{Page: 2, PageSize: 10, Total: 25}
+	require.Equal(t, 3, page.Pages())
+	require.Equal(t, 10, page.Offset())
+
+	links := page.Links(blogPageURL(url.Values{"year": {"2024"}, "page": {"2"}}))
+	require.Equal(t, model.PageLinks{
+		Self:  "/blog/page/2/?year=2024",
+		First: "/blog/?year=2024",
+		Last:  "/blog/page/3/?year=2024",
+		Prev:  "/blog/?year=2024",
+		Next:  "/blog/page/3/?year=2024",
+	}, links)
+	require.Equal(t, `</blog/?year=2024>; rel="first", </blog/?year=2024>; rel="prev", </blog/page/3/?year=2024>; rel="next", </blog/page/3/?year=2024>; rel="last"`, linkHeader(links))
+
+	empty := pagination{Page: 1, PageSize: 10}
+	require.Equal(t, 1, empty.Pages())
+	require.Equal(t, model.PageLinks{Self: "/blog/", First: "/blog/", Last: "/blog/"}, empty.Links(blogPageURL(nil)))
+}
+
+func TestParsePagination(t *testing.T) {
+	page, err := parsePagination(url.Values{})
+	require.NoError(t, err)
+	require.Equal(t, pagination{Page: 1, PageSize: defaultPageSize}, page)
+
+	page, err = parsePagination(url.Values{"page": {"3"}, "pageSize": {"5"}})
+	require.NoError(t, err)
+	require.Equal(t, pagination{Page: 3, PageSize: 5}, page)
+
+	for _, query := range []url.Values{
+		{"page": {"0"}},
+		{"page": {"first"}},
+		{"pageSize": {"0"}},
+		{"pageSize": {fmt.Sprint(maxPageSize + 1)}},
+	} {
+		_, err := parsePagination(query)
+		require.Error(t, err, query.Encode())
+	}
+}
+
+func TestParseArticleFilter(t *testing.T) {
+	filter, err := parseArticleFilter(url.Values{"year": {"2024"}, "month": {"3"}, "source": {"external"}})
+	require.NoError(t, err)
+	require.Equal(t, storage.ArticleFilter{Year: 2024, Month: 3, Source: storage.SourceExternal}, filter)
+
+	for _, query := range []url.Values{
+		{"year": {"last"}},
+		{"year": {"2024"}, "month": {"13"}},
+		{"month": {"3"}},
+		{"source": {"remote"}},
+	} {
+		_, err := parseArticleFilter(query)
+		require.Error(t, err, query.Encode())
+	}
+}
+
+func TestListArticlesJSON(t *testing.T) {
+	ctx := context.Background()
+	m := newTestModule(t, t.TempDir())
+
+	for i := 1; i <= 5; i++ {
+		article := &model.Article{
+			ID:    fmt.Sprintf("page-%d", i),
+			Slug:  fmt.Sprintf("article-%d", i),
+			Title: fmt.Sprintf("Article %d", i),
+			Date:  timePtr(time.Date(2023+i%2, time.Month(i), 1, 0, 0, 0, 0, time.UTC)),
+		}
+		if i == 5 {
+			article.Source = "https://example.com/article-5"
+		}
+		require.NoError(t, m.repository.InsertArticle(ctx, article))
+	}
+
+	h := &Handlers{repository: m.repository}
+
+	list := func(t *testing.T, query string) (*model.ArticleList, *http.Response) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		h.ListArticlesJSON(w, httptest.NewRequest(http.MethodGet, "/api/blog/articles?"+query, nil))
+
+		resp := w.Result()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result model.ArticleList
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return &result, resp
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		result, resp := list(t, "page=2&pageSize=2")
+		require.Equal(t, 5, result.Total)
+		require.Equal(t, 3, result.Pages)
+		require.Len(t, result.Articles, 2)
+		require.Equal(t, "/api/blog/articles?page=1&pageSize=2", result.Links.Prev)
+		require.Equal(t, "/api/blog/articles?page=3&pageSize=2", result.Links.Next)
+		require.Contains(t, resp.Header.Get("Link"), `rel="next"`)
+
+		last, _ := list(t, "page=3&pageSize=2")
+		require.Len(t, last.Articles, 1)
+		require.Empty(t, last.Links.Next)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		result, _ := list(t, "year=2024")
+		require.Equal(t, 3, result.Total)
+
+		result, _ = list(t, "year=2024&month=3")
+		require.Equal(t, 1, result.Total)
+		require.Equal(t, "article-3", result.Articles[0].Slug)
+
+		result, _ = list(t, "source=external")
+		require.Equal(t, 1, result.Total)
+		require.Equal(t, "article-5", result.Articles[0].Slug)
+
+		result, _ = list(t, "source=local")
+		require.Equal(t, 4, result.Total)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ListArticlesJSON(w, httptest.NewRequest(http.MethodGet, "/api/blog/articles?pageSize=1000", nil))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
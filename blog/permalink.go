@@ -0,0 +1,119 @@
+package blog
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// articlePermalink is the permalink of articles without one in front matter
+const articlePermalink = "/blog/{{slug}}/"
+
+// routePaths are served by the routes of the module, so pages and
+// articles can't use them
+var routePaths = []string{"/", "/blog/", "/blog/tags/", "/feed.xml", "/sitemap.xml"}
+
+// routePrefixes are owned by routes with parameters, like `/blog/page/{n}/`
+// and `/blog/tags/{tag}/feed.xml`, so no path below them can be used
+var routePrefixes = []string{"/api/", "/assets/", "/blog/page/", "/blog/tags/"}
+
+// placeholderPattern matches a permalink placeholder like `{{slug}}` or `{{ year }}`
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// expandPermalink replaces the placeholders of a permalink with values
+// and returns the cleaned URL path. A placeholder without a value is an error.
+func expandPermalink(permalink string, values map[string]string) (string, error) {
+	var missing []string
+	result := placeholderPattern.ReplaceAllStringFunc(permalink, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, match)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("permalink %q: unknown placeholder %s", permalink, strings.Join(missing, ", "))
+	}
+	return cleanPermalink(result), nil
+}
+
+// cleanPermalink returns the permalink as a clean path with a leading
+// slash, keeping the trailing slash of a directory
+func cleanPermalink(permalink string) string {
+	result := path.Clean("/" + permalink)
+	if strings.HasSuffix(permalink, "/") && result != "/" {
+		result += "/"
+	}
+	return result
+}
+
+// articlePath returns the URL path of an article from the permalink in
+// front matter, or the default `/blog/{{slug}}/`. The placeholders are
+// `{{slug}}`, `{{id}}`, and `{{year}}`, `{{month}}` and `{{day}}` of the date.
+func articlePath(permalink string, article *model.Article) (string, error) {
+	if permalink == "" {
+		permalink = articlePermalink
+	}
+
+	values := map[string]string{
+		"slug": article.Slug,
+		"id":   article.ID,
+	}
+	if article.Date != nil {
+		values["year"] = article.Date.Format("2006")
+		values["month"] = article.Date.Format("01")
+		values["day"] = article.Date.Format("02")
+	}
+	return expandPermalink(permalink, values)
+}
+
+// outputFile returns the file a URL path is generated to, relative to the
+// output directory. Paths without an extension are written to index.html
+// in the directory of the path.
+func outputFile(urlPath string) string {
+	name := strings.TrimPrefix(urlPath, "/")
+	if name == "" || strings.HasSuffix(name, "/") || path.Ext(name) == "" {
+		name = path.Join(name, "index.html")
+	}
+	return filepath.FromSlash(name)
+}
+
+// permalinks records the sources claiming URL paths. Paths generated to
+// the same file, like `/about` and `/about/`, are the same path.
+type permalinks map[string]string
+
+// newPermalinks returns permalinks with the route paths of the module claimed
+func newPermalinks() permalinks {
+	result := make(permalinks)
+	for _, urlPath := range routePaths {
+		result[outputFile(urlPath)] = "route " + urlPath
+	}
+	return result
+}
+
+// Claim records that source is served at urlPath, and returns an error
+// if another source or a route already is
+func (p permalinks) Claim(urlPath, source string) error {
+	key := outputFile(urlPath)
+	if other, ok := p[key]; ok {
+		return fmt.Errorf("%s and %s are both served at %s", other, source, urlPath)
+	}
+	for _, prefix := range routePrefixes {
+		if strings.HasPrefix(cleanPermalink(urlPath), prefix) {
+			return fmt.Errorf("route %s* and %s are both served at %s", prefix, source, urlPath)
+		}
+	}
+	p[key] = source
+	return nil
+}
+
+// ClaimRoute records that a route of the module is served at urlPath,
+// for the paths of routes with parameters
+func (p permalinks) ClaimRoute(urlPath string) {
+	p[outputFile(urlPath)] = "route " + urlPath
+}
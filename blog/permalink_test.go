@@ -0,0 +1,110 @@
+package blog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func TestArticlePath(t *testing.T) {
+	date := time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC)
+	article := &model.Article{ID: "hello-id", Slug: "hello", Date: &date}
+
+	tests := map[string]string{
+		"":                    "/blog/hello/",
+		"/{{year}}/{{slug}}/": "/2024/hello/",
+		"{{ year }}/{{ month }}/{{ day }}/{{ slug }}.html": "/2024/03/07/hello.html",
+		"/posts/{{id}}":                 "/posts/hello-id",
+		"/posts//{{slug}}/../{{slug}}/": "/posts/hello/",
+	}
+	for permalink, want := range tests {
+		got, err := articlePath(permalink, article)
+		require.NoError(t, err, permalink)
+		require.Equal(t, want, got, permalink)
+	}
+
+	_, err := articlePath("/{{category}}/{{slug}}/", article)
+	require.ErrorContains(t, err, "unknown placeholder {{category}}")
+
+	_, err = articlePath("/{{year}}/{{slug}}/", &model.Article{Slug: "undated"})
+	require.ErrorContains(t, err, "unknown placeholder {{year}}")
+}
+
+func TestPermalinks(t *testing.T) {
+	require.Equal(t, filepath.FromSlash("blog/hello/index.html"), outputFile("/blog/hello/"))
+	require.Equal(t, filepath.FromSlash("about/index.html"), outputFile("/about"))
+	require.Equal(t, "feed.xml", outputFile("/feed.xml"))
+	require.Equal(t, "index.html", outputFile("/"))
+
+	claims := newPermalinks()
+	require.NoError(t, claims.Claim("/about/", "pages/about.vuego"))
+	require.ErrorContains(t, claims.Claim("/about", "about.md"), "pages/about.vuego and about.md are both served at /about")
+	require.ErrorContains(t, claims.Claim("/sitemap.xml", "sitemap.md"), "route /sitemap.xml and sitemap.md")
+
+	// Paths below routes with parameters
+	require.ErrorContains(t, claims.Claim("/blog/page/2/", "page.md"), "route /blog/page/* and page.md")
+	require.ErrorContains(t, claims.Claim("/api/blog/articles", "api.md"), "route /api/*")
+	require.ErrorContains(t, claims.Claim("/blog/tags/go/feed.xml", "feed.md"), "route /blog/tags/*")
+	require.NoError(t, claims.Claim("/blog/pages/", "pages.md"))
+
+	claims.ClaimRoute("/blog/page/2/")
+	require.ErrorContains(t, claims.Claim("/blog/page/2", "page.md"), "route /blog/page/2/ and page.md")
+}
+
+func TestModulePermalinks(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	writeFile(t, filepath.Join(dataDir, "dated.md"), "---\ntitle: Dated\ndate: 2024-01-15\npermalink: /{{year}}/{{slug}}/\n---\n\nBody\n")
+
+	m := newTestModule(t, dataDir)
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	article, err := m.repository.GetArticleBySlug(ctx, "dated")
+	require.NoError(t, err)
+	require.Equal(t, "/2024/dated/", article.URL)
+
+	h := &Handlers{repository: m.repository}
+	router := chi.NewRouter()
+	router.Get("/blog/{slug}/", h.GetArticleHTML)
+	router.NotFound(h.NotFound)
+
+	t.Run("serves the article at the permalink", func(t *testing.T) {
+		for _, path := range []string{"/2024/dated/", "/2024/dated"} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			r.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code, path)
+			require.Contains(t, w.Body.String(), `"URL":"/2024/dated/"`, path)
+		}
+	})
+
+	t.Run("redirects the default path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blog/dated/", nil))
+		require.Equal(t, http.StatusMovedPermanently, w.Code)
+		require.Equal(t, "/2024/dated/", w.Header().Get("Location"))
+	})
+
+	t.Run("refuses a taken permalink", func(t *testing.T) {
+		writeFile(t, filepath.Join(dataDir, "other.md"), "---\ntitle: Other\npermalink: /2024/dated/\n---\n\nBody\n")
+		_, err := m.ScanMarkdownFiles(ctx)
+		require.ErrorContains(t, err, "url /2024/dated/ is taken by article dated")
+	})
+
+	t.Run("refuses a route", func(t *testing.T) {
+		writeFile(t, filepath.Join(dataDir, "other.md"), "---\ntitle: Other\npermalink: /feed.xml\n---\n\nBody\n")
+		_, err := m.ScanMarkdownFiles(ctx)
+		require.ErrorContains(t, err, "route /feed.xml")
+	})
+}
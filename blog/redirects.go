@@ -0,0 +1,44 @@
+package blog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// redirectsFile lists site redirects, mapping old paths to new paths or URLs
+const redirectsFile = "config/redirects.yml"
+
+// loadRedirects reads the site redirects file, if it exists
+func loadRedirects(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var redirects map[string]string
+	if err := yaml.Unmarshal(data, &redirects); err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", filename, err)
+	}
+	return redirects, nil
+}
+
+// indexRedirects stores the site redirects from the redirects file
+func (m *Module) indexRedirects(ctx context.Context) error {
+	redirects, err := loadRedirects(m.redirectsFile)
+	if err != nil {
+		return err
+	}
+
+	if err := m.repository.SetSiteRedirects(ctx, redirects); err != nil {
+		return fmt.Errorf("failed to store redirects: %w", err)
+	}
+	return nil
+}
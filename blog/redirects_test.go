@@ -0,0 +1,75 @@
+package blog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirects(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	writeFile(t, filepath.Join(dataDir, "moved.md"), "---\ntitle: Moved\ndate: 2024-01-15\naliases:\n  - old-moved\nredirect_from: /2019/moved.html\n---\n\nBody\n")
+
+	redirectsFile := filepath.Join(t.TempDir(), "redirects.yml")
+	writeFile(t, redirectsFile, "/about/: /resume/\n/twitter: https://example.com/\n")
+
+	m := newTestModule(t, dataDir)
+	m.redirectsFile = redirectsFile
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	h := &Handlers{repository: m.repository}
+
+	t.Run("serves redirects", func(t *testing.T) {
+		tests := map[string]string{
+			"/blog/old-moved/":  "/blog/moved/",
+			"/2019/moved.html":  "/blog/moved/",
+			"/about?utm=1":      "/resume/?utm=1",
+			"/twitter":          "https://example.com/",
+			"/blog/old-moved/x": "",
+		}
+		for path, location := range tests {
+			w := httptest.NewRecorder()
+			h.NotFound(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if location == "" {
+				require.Equal(t, http.StatusNotFound, w.Code, path)
+				continue
+			}
+			require.Equal(t, http.StatusMovedPermanently, w.Code, path)
+			require.Equal(t, location, w.Header().Get("Location"), path)
+		}
+	})
+
+	t.Run("generates stubs", func(t *testing.T) {
+		outputDir := t.TempDir()
+		existing := filepath.Join(outputDir, "about", "index.html")
+		writeFile(t, existing, "page")
+
+		g := NewGenerator(m, outputDir)
+		g.SetRedirectsFile(true)
+		require.NoError(t, g.generateRedirects(ctx, h))
+
+		stub, err := os.ReadFile(filepath.Join(outputDir, "blog", "old-moved", "index.html"))
+		require.NoError(t, err)
+		require.Contains(t, string(stub), `<meta http-equiv="refresh" content="0; url=/blog/moved/">`)
+
+		_, err = os.Stat(filepath.Join(outputDir, "2019", "moved.html"))
+		require.NoError(t, err)
+
+		page, err := os.ReadFile(existing)
+		require.NoError(t, err)
+		require.Equal(t, "page", string(page), "pages must not be replaced")
+
+		list, err := os.ReadFile(filepath.Join(outputDir, "_redirects"))
+		require.NoError(t, err)
+		require.Contains(t, string(list), "/blog/old-moved /blog/moved/ 301\n")
+		require.Contains(t, string(list), "/twitter https://example.com/ 301\n")
+	})
+}
@@ -0,0 +1,160 @@
+package blog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/titpetric/platform-example/blog/markdown"
+	"github.com/titpetric/platform-example/blog/model"
+	"github.com/titpetric/platform-example/blog/storage"
+)
+
+// renderCacheSize is the number of entries each in-memory cache holds
+const renderCacheSize = 256
+
+// memoryCache is a bounded in-memory cache evicting the oldest entries first
+type memoryCache[V any] struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string]V
+	keys    []string
+}
+
+// newMemoryCache creates a cache holding up to limit entries
+func newMemoryCache[V any](limit int) *memoryCache[V] {
+	return &memoryCache[V]{
+		limit:   limit,
+		entries: make(map[string]V, limit),
+	}
+}
+
+// Get returns the cached value for key
+func (c *memoryCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.entries[key]
+	return value, ok
+}
+
+// Set stores value for key, evicting the oldest entry when the cache is full
+func (c *memoryCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		if len(c.keys) >= c.limit {
+			delete(c.entries, c.keys[0])
+			c.keys = c.keys[1:]
+		}
+		c.keys = append(c.keys, key)
+	}
+	c.entries[key] = value
+}
+
+// rendered is the HTML of markdown content with its table of contents
+type rendered struct {
+	HTML []byte
+
+	// TOC is the heading tree encoded as JSON, empty without headings
+	TOC string
+}
+
+// renderCache renders article markdown to HTML and caches the result by
+// content hash. The hash covers the markdown and the renderer version with
+// its extensions, so an edited article or renderer misses the cache instead
+// of reading stale HTML. Entries are kept in memory, and in the database
+// when a repository is set, so a cold start doesn't highlight every code
+// block again.
+type renderCache struct {
+	renderer   *markdown.Renderer
+	memory     *memoryCache[rendered]
+	repository *storage.Storage
+}
+
+// newRenderCache creates an in-memory render cache
+func newRenderCache() *renderCache {
+	return &renderCache{
+		renderer: markdown.NewRenderer(),
+		memory:   newMemoryCache[rendered](renderCacheSize),
+	}
+}
+
+// contentHash returns the render cache key for markdown content
+func contentHash(content []byte) string {
+	return renderKey(markdown.Version, content)
+}
+
+// renderKey hashes markdown content with the version of the renderer
+func renderKey(version string, content []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(version + "\x00"))
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Render returns the HTML for markdown content, rendering it on a cache miss
+func (c *renderCache) Render(ctx context.Context, content []byte) []byte {
+	return c.RenderTOC(ctx, content).HTML
+}
+
+// RenderTOC returns the HTML for markdown content and its table of
+// contents, parsing the content once on a cache miss. The heading ids
+// match the ids in the HTML.
+func (c *renderCache) RenderTOC(ctx context.Context, content []byte) rendered {
+	key := renderKey(c.renderer.Version(), content)
+	if result, ok := c.memory.Get(key); ok {
+		return result
+	}
+
+	if c.repository != nil {
+		if entry, err := c.repository.GetRenderCache(ctx, key); err == nil {
+			result := rendered{HTML: []byte(entry.HTML), TOC: entry.TOC}
+			c.memory.Set(key, result)
+			return result
+		}
+	}
+
+	html, headings := c.renderer.RenderHeadings(content)
+	result := rendered{HTML: html}
+	if len(headings) > 0 {
+		// Headings are plain strings and always encode
+		encoded, _ := json.Marshal(headings)
+		result.TOC = string(encoded)
+	}
+	c.memory.Set(key, result)
+
+	if c.repository != nil {
+		entry := &model.RenderCache{
+			Hash: key,
+			HTML: string(result.HTML),
+			TOC:  result.TOC,
+		}
+		// The cache is an optimization, rendering succeeded regardless
+		if err := c.repository.SaveRenderCache(ctx, entry); err != nil {
+			log.Printf("[blog] can't persist rendered html: %v", err)
+		}
+	}
+
+	return result
+}
+
+// Prune removes the stored HTML of everything but contents, rendered with
+// the current renderer, so HTML of edited articles and of previous renderer
+// or theme versions doesn't pile up
+func (c *renderCache) Prune(ctx context.Context, contents [][]byte) (int64, error) {
+	if c.repository == nil {
+		return 0, nil
+	}
+
+	version := c.renderer.Version()
+	keep := make([]string, 0, len(contents))
+	for _, content := range contents {
+		keep = append(keep, renderKey(version, content))
+	}
+	return c.repository.PruneRenderCache(ctx, keep)
+}
@@ -0,0 +1,111 @@
+package blog
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/titpetric/platform-example/blog/markdown"
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := newMemoryCache[[]byte](2)
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Set("a", []byte("3"))
+
+	value, ok := cache.Get("a")
+	require.True(t, ok)
+	require.Equal(t, "3", string(value))
+
+	// The oldest entry is evicted
+	cache.Set("c", []byte("4"))
+	_, ok = cache.Get("a")
+	require.False(t, ok)
+	_, ok = cache.Get("b")
+	require.True(t, ok)
+	_, ok = cache.Get("c")
+	require.True(t, ok)
+}
+
+func TestRenderCache(t *testing.T) {
+	ctx := context.Background()
+	m := newTestModule(t, t.TempDir())
+
+	content := []byte("# Hello\n\n```go\nfmt.Println(1)\n```\n")
+	key := contentHash(content)
+	require.NotEqual(t, key, contentHash([]byte("# Hello again\n")))
+
+	t.Run("memory", func(t *testing.T) {
+		cache := newRenderCache()
+		html := cache.Render(ctx, content)
+		require.Contains(t, string(html), `<h1 id="hello">Hello</h1>`)
+
+		cached, ok := cache.memory.Get(key)
+		require.True(t, ok)
+		require.Equal(t, html, cached.HTML)
+		require.Equal(t, `[{"id":"hello","title":"Hello","level":1}]`, cached.TOC)
+	})
+
+	t.Run("persistent", func(t *testing.T) {
+		cache := newRenderCache()
+		cache.repository = m.repository
+		html := cache.Render(ctx, content)
+
+		entry, err := m.repository.GetRenderCache(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, string(html), entry.HTML)
+		require.Equal(t, `[{"id":"hello","title":"Hello","level":1}]`, entry.TOC)
+
+		// A cold cache reads the stored HTML and headings instead of rendering
+		require.NoError(t, m.repository.SaveRenderCache(ctx, &model.RenderCache{Hash: key, HTML: "<p>stored</p>", TOC: "[]"}))
+
+		cold := newRenderCache()
+		cold.repository = m.repository
+		result := cold.RenderTOC(ctx, content)
+		require.Equal(t, "<p>stored</p>", string(result.HTML))
+		require.Equal(t, "[]", result.TOC)
+	})
+
+	t.Run("extensions", func(t *testing.T) {
+		m := newTestModule(t, t.TempDir())
+		m.SetMarkdownExtensions(markdown.NewTransform("upper/1", func(doc *ast.Document, _ []byte) {
+			heading := doc.FirstChild()
+			heading.ReplaceChild(heading, heading.FirstChild(), ast.NewString([]byte("HELLO")))
+		}))
+
+		// Extensions change the cache key, so HTML of another renderer isn't read
+		html := m.render.Render(ctx, content)
+		require.Contains(t, string(html), `<h1 id="hello">HELLO</h1>`)
+		_, ok := m.render.memory.Get(key)
+		require.False(t, ok)
+	})
+
+	t.Run("pruning", func(t *testing.T) {
+		dataDir := t.TempDir()
+		path := filepath.Join(dataDir, "pruned.md")
+		writeArticle(t, path, "Pruned", time.Now())
+
+		m := newTestModule(t, dataDir)
+		m.SetPersistentRenderCache(true)
+		_, err := m.ScanMarkdownFiles(ctx)
+		require.NoError(t, err)
+
+		// HTML of a previous renderer version is stored, and unused
+		stale := renderKey("0", []byte("Body of Pruned\n"))
+		require.NoError(t, m.repository.SaveRenderCache(ctx, &model.RenderCache{Hash: stale, HTML: "<p>stale</p>"}))
+
+		_, err = m.ScanMarkdownFiles(ctx)
+		require.NoError(t, err)
+
+		_, err = m.repository.GetRenderCache(ctx, stale)
+		require.Error(t, err)
+		_, err = m.repository.GetRenderCache(ctx, renderKey(m.render.renderer.Version(), []byte("Body of Pruned\n")))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,11 @@
+-- Drop the blog schema
+DROP TRIGGER IF EXISTS article_tag_delete;
+DROP TRIGGER IF EXISTS article_fts_delete;
+DROP TRIGGER IF EXISTS article_fts_update;
+DROP TRIGGER IF EXISTS article_fts_insert;
+
+DROP TABLE IF EXISTS render_cache;
+DROP TABLE IF EXISTS article_tag;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS article_fts;
+DROP TABLE IF EXISTS article;
@@ -25,3 +25,53 @@ CREATE INDEX IF NOT EXISTS idx_article_layout ON article(layout);
 
 -- Index for recent articles
 CREATE INDEX IF NOT EXISTS idx_article_created_at ON article(created_at DESC);
+
+-- Full-text index over article metadata and the stripped markdown body
+CREATE VIRTUAL TABLE IF NOT EXISTS article_fts USING fts5(
+    slug,
+    title,
+    description,
+    body,
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+-- Keep the full-text index in sync with article rows; the body is
+-- filled in separately by the indexer after the article is stored.
+CREATE TRIGGER IF NOT EXISTS article_fts_insert AFTER INSERT ON article BEGIN
+    DELETE FROM article_fts WHERE slug = new.slug;
+    INSERT INTO article_fts (slug, title, description, body) VALUES (new.slug, new.title, new.description, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_fts_update AFTER UPDATE ON article BEGIN
+    UPDATE article_fts SET slug = new.slug, title = new.title, description = new.description WHERE slug = old.slug;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_fts_delete AFTER DELETE ON article BEGIN
+    DELETE FROM article_fts WHERE slug = old.slug;
+END;
+
+-- Tags assigned to articles from front matter
+CREATE TABLE IF NOT EXISTS tag (
+    `slug` TEXT PRIMARY KEY,
+    `name` TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS article_tag (
+    `article_id` TEXT NOT NULL,
+    `tag` TEXT NOT NULL,
+    PRIMARY KEY (`article_id`, `tag`)
+);
+
+-- Index for listing articles by tag
+CREATE INDEX IF NOT EXISTS idx_article_tag_tag ON article_tag(tag);
+
+CREATE TRIGGER IF NOT EXISTS article_tag_delete AFTER DELETE ON article BEGIN
+    DELETE FROM article_tag WHERE article_id = old.id;
+END;
+
+-- Rendered article HTML keyed by content hash
+CREATE TABLE IF NOT EXISTS render_cache (
+    `hash` TEXT PRIMARY KEY,
+    `html` TEXT NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
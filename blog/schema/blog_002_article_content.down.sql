@@ -0,0 +1,9 @@
+-- Article content
+ALTER TABLE article DROP COLUMN `content_hash`;
+ALTER TABLE article DROP COLUMN `word_count`;
+ALTER TABLE article DROP COLUMN `body_html`;
+ALTER TABLE article DROP COLUMN `body_markdown`;
+
+-- Publishing states
+ALTER TABLE article DROP COLUMN `unlisted`;
+ALTER TABLE article DROP COLUMN `draft`;
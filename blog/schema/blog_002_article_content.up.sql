@@ -0,0 +1,9 @@
+-- Publishing states
+ALTER TABLE article ADD COLUMN `draft` BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE article ADD COLUMN `unlisted` BOOLEAN NOT NULL DEFAULT 0;
+
+-- Article content, so the database is the source of truth
+ALTER TABLE article ADD COLUMN `body_markdown` TEXT NOT NULL DEFAULT '';
+ALTER TABLE article ADD COLUMN `body_html` TEXT NOT NULL DEFAULT '';
+ALTER TABLE article ADD COLUMN `word_count` INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE article ADD COLUMN `content_hash` TEXT NOT NULL DEFAULT '';
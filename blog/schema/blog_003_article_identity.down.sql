@@ -0,0 +1,3 @@
+-- Article IDs stay derived from the slug
+DROP TRIGGER IF EXISTS article_slug_delete;
+DROP TABLE IF EXISTS article_slug;
@@ -0,0 +1,18 @@
+-- Article IDs were the slug with a timestamp, and changed on every scan.
+-- Stable IDs default to the slug.
+UPDATE article_tag SET article_id = (SELECT slug FROM article WHERE article.id = article_tag.article_id)
+    WHERE article_id IN (SELECT id FROM article);
+UPDATE article SET id = slug;
+
+-- Previous slugs of renamed articles, so old URLs can redirect
+CREATE TABLE IF NOT EXISTS article_slug (
+    `slug` TEXT PRIMARY KEY,
+    `article_id` TEXT NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_slug_article_id ON article_slug(article_id);
+
+CREATE TRIGGER IF NOT EXISTS article_slug_delete AFTER DELETE ON article BEGIN
+    DELETE FROM article_slug WHERE article_id = old.id;
+END;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS redirect_article_delete;
+DROP TABLE IF EXISTS redirect;
@@ -0,0 +1,14 @@
+-- Redirects from front matter aliases and config/redirects.yml
+CREATE TABLE IF NOT EXISTS redirect (
+    `path` TEXT PRIMARY KEY,
+    `target` TEXT NOT NULL,
+    `article_id` TEXT NOT NULL DEFAULT '',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for replacing the aliases of an article
+CREATE INDEX IF NOT EXISTS idx_redirect_article_id ON redirect(article_id);
+
+CREATE TRIGGER IF NOT EXISTS redirect_article_delete AFTER DELETE ON article BEGIN
+    DELETE FROM redirect WHERE article_id = old.id;
+END;
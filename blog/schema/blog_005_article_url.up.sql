@@ -0,0 +1,2 @@
+-- Articles are looked up by permalink, and two articles can't share one
+CREATE UNIQUE INDEX IF NOT EXISTS idx_article_url ON article(url) WHERE url <> '';
@@ -0,0 +1,2 @@
+-- Headings of the article body as a JSON tree, for the table of contents
+ALTER TABLE article ADD COLUMN `toc` TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE render_cache DROP COLUMN `toc`;
@@ -0,0 +1,4 @@
+-- Headings of the rendered HTML as a JSON tree, so articles are parsed once.
+-- Entries without one are rendered again.
+DELETE FROM render_cache;
+ALTER TABLE render_cache ADD COLUMN `toc` TEXT NOT NULL DEFAULT '';
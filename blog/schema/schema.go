@@ -1,9 +1,111 @@
 package schema
 
-import _ "embed"
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+)
 
-// InitialSchema contains the initial blog schema
-// This is executed by the storage package on first use
-//
-//go:embed blog.up.sql
-var InitialSchema string
+//go:embed *.sql
+var migrationFS embed.FS
+
+// Migration is a schema file applied in order of file name
+type Migration struct {
+	// Name is the file name, e.g. `blog.up.sql`
+	Name string
+
+	// SQL holds the statements of the migration
+	SQL string
+
+	// Down holds the statements reverting the migration, from the
+	// matching `.down.sql` file. It's empty if there's no such file.
+	Down string
+}
+
+// Checksum returns a hash of the migration statements, so changes to
+// an applied migration can be detected
+func (m Migration) Checksum() string {
+	hash := sha256.Sum256([]byte(m.SQL))
+	return hex.EncodeToString(hash[:])
+}
+
+// Migrations returns the blog schema migrations ordered by file name.
+// `blog.up.sql` creates the initial schema, later files change it.
+func Migrations() []Migration {
+	names, err := fs.Glob(migrationFS, "*.up.sql")
+	if err != nil {
+		panic(err)
+	}
+	sort.Strings(names)
+
+	result := make([]Migration, 0, len(names))
+	for _, name := range names {
+		data, err := migrationFS.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+		down, err := migrationFS.ReadFile(strings.TrimSuffix(name, ".up.sql") + ".down.sql")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			panic(err)
+		}
+		result = append(result, Migration{
+			Name: name,
+			SQL:  string(data),
+			Down: string(down),
+		})
+	}
+	return result
+}
+
+// InitialSchema contains the blog schema with all migrations applied,
+// for creating a new database in a single step.
+var InitialSchema = func() string {
+	var sb strings.Builder
+	for _, migration := range Migrations() {
+		sb.WriteString(migration.SQL)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}()
+
+// Statements splits a migration into statements. Statements end with a
+// semicolon at the end of a line, trigger bodies end with `END;`.
+func Statements(sql string) []string {
+	var (
+		result  []string
+		current strings.Builder
+		trigger bool
+	)
+
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		upper := strings.ToUpper(trimmed)
+		if strings.HasPrefix(upper, "CREATE TRIGGER") {
+			trigger = true
+		}
+
+		if !strings.HasSuffix(trimmed, ";") || (trigger && upper != "END;") {
+			continue
+		}
+
+		result = append(result, strings.TrimSpace(current.String()))
+		current.Reset()
+		trigger = false
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
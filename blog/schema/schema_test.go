@@ -1 +1,44 @@
 package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/schema"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations := schema.Migrations()
+	require.GreaterOrEqual(t, len(migrations), 2)
+	require.Equal(t, "blog.up.sql", migrations[0].Name)
+
+	for i := 1; i < len(migrations); i++ {
+		require.Less(t, migrations[i-1].Name, migrations[i].Name)
+	}
+
+	for _, migration := range migrations {
+		require.NotEmpty(t, migration.Down, "%s has no down migration", migration.Name)
+		require.Len(t, migration.Checksum(), 64)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	sql := `-- Comment
+CREATE TABLE t (
+    id TEXT
+);
+
+CREATE TRIGGER t_insert AFTER INSERT ON t BEGIN
+    INSERT INTO log VALUES (new.id);
+    DELETE FROM log WHERE id = '';
+END;
+
+ALTER TABLE t ADD COLUMN name TEXT;
+`
+	statements := schema.Statements(sql)
+	require.Len(t, statements, 3)
+	require.Equal(t, "CREATE TABLE t (\n    id TEXT\n);", statements[0])
+	require.Contains(t, statements[1], "DELETE FROM log")
+	require.Equal(t, "ALTER TABLE t ADD COLUMN name TEXT;", statements[2])
+}
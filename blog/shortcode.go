@@ -0,0 +1,147 @@
+package blog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/platform-example/blog/layout"
+	"github.com/titpetric/platform-example/blog/markdown"
+)
+
+// shortcodeAlias is a shortcode rendering a theme component of another
+// name, with attributes renamed to the data of the component
+type shortcodeAlias struct {
+	component string
+	attrs     map[string]string
+}
+
+// shortcodeAliases are the shortcodes that aren't named after a component
+var shortcodeAliases = map[string]shortcodeAlias{
+	"youtube": {
+		component: "lite-youtube",
+		attrs:     map[string]string{"id": "videoId"},
+	},
+}
+
+// themeShortcodes renders shortcodes in articles with the components of the
+// theme, components/<name>.vuego. Attributes are the data of the component,
+// with dashed names in camel case, e.g. `play-label` is `playLabel`. The
+// content of the shortcode fills the `<slot>` of the component, and is also
+// available as `content`.
+type themeShortcodes struct {
+	fs       fs.FS
+	renderer *layout.Renderer
+	theme    *themeVersion
+}
+
+// newThemeShortcodes creates the shortcodes of the components in theme
+func newThemeShortcodes(theme *themeVersion) *themeShortcodes {
+	return &themeShortcodes{
+		fs:       theme.fs,
+		renderer: layout.NewRenderer(slotFS{theme.fs}, nil),
+		theme:    theme,
+	}
+}
+
+// Extension returns the markdown extension rendering the shortcodes.
+// Its name holds the version of the theme components, so the version of
+// the renderer changes with them, and reindexing renders articles again.
+// Other theme files aren't part of the stored HTML.
+func (s *themeShortcodes) Extension() markdown.Extension {
+	return &themeExtension{
+		Extension: markdown.NewShortcodes(s.Render),
+		theme:     s.theme,
+	}
+}
+
+// Render renders the component of the shortcode name. Errors are logged,
+// as the article renders with the shortcode as text.
+func (s *themeShortcodes) Render(name string, attrs map[string]string, content []byte) ([]byte, error) {
+	html, err := s.render(name, attrs, content)
+	if err != nil {
+		log.Printf("[blog] can't render shortcode: %v", err)
+	}
+	return html, err
+}
+
+// render renders the component of the shortcode name
+func (s *themeShortcodes) render(name string, attrs map[string]string, content []byte) ([]byte, error) {
+	alias, ok := shortcodeAliases[name]
+	if !ok {
+		alias.component = name
+	}
+
+	filename := path.Join("components", alias.component+".vuego")
+	if _, err := fs.Stat(s.fs, filename); err != nil {
+		return nil, fmt.Errorf("unknown shortcode %q", name)
+	}
+
+	data := make(map[string]any, len(attrs)+1)
+	for key, value := range attrs {
+		// Components bind attributes as is, a quote would end the attribute
+		if strings.Contains(value, `"`) {
+			return nil, fmt.Errorf("shortcode %q: attribute %s can't contain quotes", name, key)
+		}
+		if renamed, ok := alias.attrs[key]; ok {
+			key = renamed
+		}
+		data[camelCase(key)] = value
+	}
+	data["content"] = string(content)
+
+	var buf bytes.Buffer
+	if err := s.renderer.RenderComponent(context.Background(), &buf, filename, data); err != nil {
+		return nil, fmt.Errorf("shortcode %q: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// slotPattern matches the slot of a component, `<slot></slot>` or `<slot />`
+var slotPattern = regexp.MustCompile(`<slot\s*(/>|>\s*</slot>)`)
+
+// slotFS reads components with their slot filled by the content of the
+// shortcode, as the template engine doesn't support slots
+type slotFS struct {
+	fs.FS
+}
+
+// ReadFile reads the file name, replacing the slots of templates
+func (s slotFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(s.FS, name)
+	if err != nil || path.Ext(name) != ".vuego" {
+		return data, err
+	}
+	return slotPattern.ReplaceAll(data, []byte(`<template v-html="content"></template>`)), nil
+}
+
+// camelCase converts a dashed name like `play-label` to `playLabel`
+func camelCase(name string) string {
+	parts := strings.Split(name, "-")
+	for i, part := range parts[1:] {
+		if part != "" {
+			parts[i+1] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// themeExtension adds the version of the theme components to the name of an extension
+type themeExtension struct {
+	markdown.Extension
+	theme *themeVersion
+}
+
+// Name returns the name of the extension with the version of the theme components
+func (e *themeExtension) Name() string {
+	version := e.theme.Components()
+	if len(version) > 12 {
+		version = version[:12]
+	}
+	return e.Extension.Name() + "@" + version
+}
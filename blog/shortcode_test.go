@@ -0,0 +1,112 @@
+package blog
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortcodes(t *testing.T) {
+	ctx := context.Background()
+	m := newTestModule(t, t.TempDir())
+
+	t.Run("youtube", func(t *testing.T) {
+		html := string(m.render.Render(ctx, []byte("Watch:\n\n{{< youtube id=\"dQw4w9WgXcQ\" play-label=\"Play the video\" >}}\n")))
+		// Bound attributes render in no particular order
+		require.Contains(t, html, `<lite-youtube class="lite-youtube" `)
+		require.Contains(t, html, ` videoid="dQw4w9WgXcQ"`)
+		require.Contains(t, html, ` playlabel="Play the video"`)
+		require.Contains(t, html, "class LiteYTEmbed")
+		require.NotContains(t, html, "<html", "components aren't wrapped in layouts")
+	})
+
+	t.Run("content", func(t *testing.T) {
+		html := string(m.render.Render(ctx, []byte("{{< info-cta >}}\nSubscribe to the **feed**.\n{{< /info-cta >}}\n")))
+		require.Contains(t, html, "<div class=\"info-cta\">\n<p>Subscribe to the <strong>feed</strong>.</p>\n</div>")
+	})
+
+	t.Run("slot", func(t *testing.T) {
+		theme := fstest.MapFS{
+			"components/slotted.vuego": {Data: []byte("<aside><slot /></aside>")},
+			"components/bound.vuego":   {Data: []byte(`<aside v-html="content"></aside>`)},
+		}
+		shortcodes := newThemeShortcodes(newThemeVersion(theme))
+
+		for _, name := range []string{"slotted", "bound"} {
+			html, err := shortcodes.Render(name, nil, []byte("<p>Hello</p>"))
+			require.NoError(t, err)
+			require.Equal(t, "<aside><p>Hello</p></aside>", strings.ReplaceAll(string(html), "\n", ""), name)
+		}
+	})
+
+	t.Run("escaping", func(t *testing.T) {
+		// Components bind attributes as is, so quotes can't end an attribute
+		html := string(m.render.Render(ctx, []byte(`{{< youtube id='"><script>alert(1)</script>' >}}`+"\n")))
+		require.NotContains(t, html, `"><script>alert(1)`)
+		require.NotContains(t, html, "<lite-youtube")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		html := string(m.render.Render(ctx, []byte("{{< ../layouts/base >}}\n\n{{< missing >}}\n")))
+		require.Contains(t, html, "<p>{{&lt; ../layouts/base &gt;}}</p>")
+		require.Contains(t, html, "<p>{{&lt; missing &gt;}}</p>")
+	})
+
+	t.Run("version", func(t *testing.T) {
+		version := m.render.renderer.Version()
+		require.True(t, strings.Contains(version, "+shortcodes/1@"), version)
+	})
+}
+
+func TestShortcodesThemeChange(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	writeFile(t, filepath.Join(dataDir, "noted.md"), "---\ntitle: Noted\ndate: 2024-01-15\n---\n\n{{< note >}}\n")
+
+	theme := fstest.MapFS{
+		"components/note.vuego": {Data: []byte("<aside>First</aside>")},
+	}
+	m := newTestModule(t, dataDir)
+	// The components are in the live theme directory, over an empty embedded theme
+	m.shortcodes = newThemeShortcodes(newThemeVersion(NewOverlayFS(theme, fstest.MapFS{})))
+	m.SetMarkdownExtensions()
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	article, err := m.repository.GetArticleBySlug(ctx, "noted")
+	require.NoError(t, err)
+	require.Contains(t, article.BodyHTML, "<aside>First</aside>")
+	require.False(t, m.renderVersionChanged())
+
+	// Other theme files aren't rendered into articles
+	theme["assets/css/site.css"] = &fstest.MapFile{Data: []byte("body {}")}
+	require.True(t, m.shortcodes.theme.Refresh())
+	require.False(t, m.renderVersionChanged())
+
+	// Articles store their HTML, so a changed component renders them again
+	theme["components/note.vuego"] = &fstest.MapFile{Data: []byte("<aside>Second</aside>")}
+	require.False(t, m.renderVersionChanged(), "the theme version changes when it's refreshed")
+	require.True(t, m.shortcodes.theme.Refresh())
+	require.True(t, m.renderVersionChanged())
+
+	updated, removed, err := m.reindex(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+	require.Zero(t, removed)
+	require.False(t, m.renderVersionChanged())
+
+	article, err = m.repository.GetArticleBySlug(ctx, "noted")
+	require.NoError(t, err)
+	require.Contains(t, article.BodyHTML, "<aside>Second</aside>")
+}
+
+func TestCamelCase(t *testing.T) {
+	require.Equal(t, "videoId", camelCase("video-id"))
+	require.Equal(t, "playLabel", camelCase("playLabel"))
+	require.Equal(t, "src", camelCase("src"))
+	require.Equal(t, "a", camelCase("a-"))
+}
@@ -0,0 +1,69 @@
+package blog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/titpetric/platform-example/blog/model"
+	"github.com/titpetric/platform-example/blog/view"
+)
+
+// GetSitemap returns the sitemap.xml of the site
+func (h *Handlers) GetSitemap(w http.ResponseWriter, r *http.Request) {
+	var pages []themePage
+	if h.routes != nil {
+		var err error
+		if pages, err = h.routes.Pages(); err != nil {
+			h.serverError(w, r, err)
+			return
+		}
+	}
+
+	articles, err := h.repository.GetArticles(r.Context(), 0, 9999)
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch articles: %w", err))
+		return
+	}
+
+	tags, err := h.repository.GetTags(r.Context())
+	if err != nil {
+		h.serverError(w, r, fmt.Errorf("failed to fetch tags: %w", err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	if notModified(w, r, h.articlesValidator("sitemap", articles)) {
+		return
+	}
+
+	h.writeRendered(w, r, "application/xml; charset=utf-8", func(w io.Writer) error {
+		return h.views.Sitemap(r.Context(), w, sitemapURLs(pages, articles, tags))
+	})
+}
+
+// sitemapURLs lists the home page, the blog list, the theme pages, the
+// listed articles at their permalinks, modified when they last changed,
+// and the tag pages. Pages with `sitemap: false` are left out.
+func sitemapURLs(pages []themePage, articles []model.Article, tags []model.TagCount) []view.SitemapURL {
+	result := []view.SitemapURL{{Path: "/"}, {Path: "/blog/"}}
+	for _, page := range pages {
+		if page.Sitemap {
+			result = append(result, view.SitemapURL{Path: page.Path})
+		}
+	}
+	for _, article := range articles {
+		modified := article.UpdatedAt
+		if modified == nil {
+			modified = article.Date
+		}
+		result = append(result, view.SitemapURL{Path: article.URL, Modified: modified})
+	}
+
+	result = append(result, view.SitemapURL{Path: "/blog/tags/"})
+	for _, tag := range tags {
+		result = append(result, view.SitemapURL{Path: view.TagURL(tag.Slug)})
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+package blog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func TestSitemapURLs(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	articles := []model.Article{
+		{URL: "/blog/edited/", Date: &date, UpdatedAt: &updated},
+		{URL: "/blog/dated/", Date: &date},
+	}
+
+	urls := sitemapURLs(nil, articles, nil)
+	require.Equal(t, "/blog/edited/", urls[2].Path)
+	require.Equal(t, &updated, urls[2].Modified, "lastmod is the time the article last changed")
+	require.Equal(t, "/blog/dated/", urls[3].Path)
+	require.Equal(t, &date, urls[3].Modified)
+}
@@ -2,6 +2,11 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -10,11 +15,40 @@ import (
 )
 
 // GetArticleBySlug retrieves a single article by slug
-func GetArticleBySlug(ctx context.Context, db *sqlx.DB, slug string) (*model.Article, error) {
-	query := `SELECT * FROM article	WHERE slug=? LIMIT 1`
+func GetArticleBySlug(ctx context.Context, db *sqlx.DB, visibility Visibility, slug string) (*model.Article, error) {
+	var article model.Article
+	where, args := visibility.Where("", "slug=?")
+	query := article.Select(model.WithWhere(where), model.WithLimit(0, 1))
+
+	err := db.GetContext(ctx, &article, query, append([]any{slug}, args...)...)
+	if err != nil {
+		return nil, err
+	}
 
+	return &article, nil
+}
+
+// GetArticleByURL retrieves an article by its URL path
+func GetArticleByURL(ctx context.Context, db *sqlx.DB, visibility Visibility, url string) (*model.Article, error) {
 	var article model.Article
-	err := db.GetContext(ctx, &article, query, slug)
+	where, args := visibility.Where("", "url=?")
+	query := article.Select(model.WithWhere(where), model.WithLimit(0, 1))
+
+	err := db.GetContext(ctx, &article, query, append([]any{url}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &article, nil
+}
+
+// GetArticleByPreviousSlug retrieves the article that was renamed from slug
+func GetArticleByPreviousSlug(ctx context.Context, db *sqlx.DB, visibility Visibility, slug string) (*model.Article, error) {
+	var article model.Article
+	where, args := visibility.Where("", "id=(SELECT article_id FROM article_slug WHERE slug=?)")
+	query := article.Select(model.WithWhere(where), model.WithLimit(0, 1))
+
+	err := db.GetContext(ctx, &article, query, append([]any{slug}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -23,58 +57,274 @@ func GetArticleBySlug(ctx context.Context, db *sqlx.DB, slug string) (*model.Art
 }
 
 // GetArticles retrieves all articles ordered by date descending
-func GetArticles(ctx context.Context, db *sqlx.DB, start, length int) ([]model.Article, error) {
+func GetArticles(ctx context.Context, db *sqlx.DB, visibility Visibility, start, length int) ([]model.Article, error) {
+	return FilterArticles(ctx, db, visibility, ArticleFilter{}, start, length)
+}
+
+// FilterArticles retrieves articles matching filter ordered by date descending
+func FilterArticles(ctx context.Context, db *sqlx.DB, visibility Visibility, filter ArticleFilter, start, length int) ([]model.Article, error) {
 	var article *model.Article
-	query := article.Select(model.WithOrderBy("date DESC"), model.WithLimit(start, length))
+	clause, filterArgs := filter.Where()
+	where, args := visibility.Where("", clause)
+	query := article.Select(model.WithWhere(where), model.WithOrderBy("date DESC, slug"), model.WithLimit(start, length))
 
 	var articles []model.Article
 
-	if err := db.SelectContext(ctx, &articles, query); err != nil {
+	if err := db.SelectContext(ctx, &articles, query, append(filterArgs, args...)...); err != nil {
 		return nil, err
 	}
 
 	return articles, nil
 }
 
-// SearchArticles performs a search on articles by title, description, or content
-func SearchArticles(ctx context.Context, db *sqlx.DB, find string) ([]model.Article, error) {
-	searchTerm := "%" + find + "%"
+// SearchArticles performs a ranked full-text search over article titles,
+// descriptions, slugs and bodies. The query supports "quoted phrases" and
+// prefix terms ending in `*`; all terms must match.
+func SearchArticles(ctx context.Context, db *sqlx.DB, visibility Visibility, find string) ([]model.SearchResult, error) {
+	match := ftsQuery(find)
+	if match == "" {
+		return []model.SearchResult{}, nil
+	}
 
-	var article *model.Article
-	query := article.Select(
-		model.WithWhere("title LIKE ? or description LIKE ? or slug LIKE ?"),
-		model.WithOrderBy("date DESC"),
-	)
+	where, args := visibility.Where("article.", "article_fts MATCH ?")
 
-	var articles []model.Article
-	err := db.SelectContext(ctx, &articles, query, searchTerm, searchTerm, searchTerm)
+	// Column weights for bm25 follow the article_fts column order: slug, title, description, body.
+	// Matches are delimited with control characters, as the indexed text isn't HTML.
+	query := `SELECT article.*,
+		snippet(article_fts, -1, char(2), char(3), '…', 16) AS snippet,
+		bm25(article_fts, 5.0, 10.0, 5.0, 1.0) AS rank
+	FROM article_fts
+	JOIN article ON article.slug = article_fts.slug
+	WHERE ` + where + `
+	ORDER BY rank, article.date DESC`
+
+	results := []model.SearchResult{}
+	err := db.SelectContext(ctx, &results, query, append([]any{match}, args...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	return articles, nil
+	for i := range results {
+		results[i].Snippet = markSnippet(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// snippetMarks wraps the delimited matches of an escaped snippet in <mark>
+var snippetMarks = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
+// markSnippet escapes the text of a snippet and marks its matches
+func markSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
+
+// IndexArticleBody stores the plain text body of an article in the full-text index
+func IndexArticleBody(ctx context.Context, db *sqlx.DB, slug string, body string) error {
+	_, err := db.ExecContext(ctx, "UPDATE article_fts SET body=? WHERE slug=?", body, slug)
+	return err
+}
+
+// ftsQuery converts user input into a safe FTS5 match expression. Bare words
+// and "quoted phrases" are quoted as FTS5 strings so punctuation can't break
+// the query syntax, and a trailing `*` is kept as a prefix operator.
+func ftsQuery(find string) string {
+	var terms []string
+
+	quote := func(term string) {
+		prefix := strings.HasSuffix(term, "*")
+		term = strings.TrimRight(term, "*")
+		if strings.TrimSpace(term) == "" {
+			return
+		}
+		term = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+
+	rest := strings.TrimSpace(find)
+	for rest != "" {
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end >= 0 {
+				phrase := rest[1 : end+1]
+				rest = rest[end+2:]
+				// Keep a prefix operator directly following the phrase
+				if strings.HasPrefix(rest, "*") {
+					phrase += "*"
+					rest = rest[1:]
+				}
+				quote(phrase)
+				rest = strings.TrimSpace(rest)
+				continue
+			}
+			rest = rest[1:]
+		}
+
+		fields := strings.SplitN(rest, " ", 2)
+		quote(strings.ReplaceAll(fields[0], `"`, ""))
+		rest = ""
+		if len(fields) > 1 {
+			rest = strings.TrimSpace(fields[1])
+		}
+	}
+
+	return strings.Join(terms, " ")
 }
 
-// InsertArticle inserts a new article into the database
+// InsertArticle stores an article, updating the stored article with the
+// same ID. A stored article keeps its created time, and the updated time
+// only changes when the article does. When the slug changes, the previous
+// slug is recorded so old URLs can redirect. A different article stored
+// under the same slug is replaced, one stored with the same URL is an error.
+// An article without a date is an error, as it can't be scheduled.
 func InsertArticle(ctx context.Context, db *sqlx.DB, article *model.Article) error {
+	if article.Date == nil {
+		return fmt.Errorf("article %s has no date", article.ID)
+	}
+
 	now := time.Now()
 
-	article.SetCreatedAt(now)
-	article.SetUpdatedAt(now)
-	if article.Date == nil {
-		article.SetDate(now)
+	// Dates are stored in UTC so they compare correctly with the publishing cutoff
+	article.SetDate(article.Date.UTC())
+
+	return inTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		var existing model.Article
+		err := tx.GetContext(ctx, &existing, existing.Select(model.WithWhere("id=?")), article.ID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			article.SetCreatedAt(now)
+			article.SetUpdatedAt(now)
+		case err != nil:
+			return err
+		default:
+			article.CreatedAt = existing.CreatedAt
+			article.UpdatedAt = existing.UpdatedAt
+			if articleChanged(&existing, article) {
+				article.SetUpdatedAt(now)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, existing.Delete(model.WithWhere("slug=? AND id<>?")), article.Slug, article.ID); err != nil {
+			return err
+		}
+
+		var other model.Article
+		err = tx.GetContext(ctx, &other, other.Select(model.WithWhere("url=? AND url<>'' AND id<>?"), model.WithLimit(0, 1)), article.URL, article.ID)
+		switch {
+		case err == nil:
+			return fmt.Errorf("article %s: url %s is taken by article %s (%s)", article.ID, article.URL, other.ID, other.Filename)
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		// The slug is in use again, so it no longer redirects
+		var previous *model.ArticleSlug
+		if _, err := tx.ExecContext(ctx, previous.Delete(model.WithWhere("slug=?")), article.Slug); err != nil {
+			return err
+		}
+
+		if existing.ID != "" && existing.Slug != article.Slug {
+			previous = &model.ArticleSlug{
+				Slug:      existing.Slug,
+				ArticleID: article.ID,
+			}
+			previous.SetCreatedAt(now)
+			if _, err := tx.NamedExecContext(ctx, previous.Insert(), previous); err != nil {
+				return err
+			}
+		}
+
+		query := article.Insert() + " ON CONFLICT(id) DO UPDATE SET " + excludedSet(model.ArticleFields, model.ArticlePrimaryFields)
+		_, err = tx.NamedExecContext(ctx, query, article)
+		return err
+	})
+}
+
+// articleChanged returns true if the stored fields of the articles differ,
+// not counting the created and updated times
+func articleChanged(a, b *model.Article) bool {
+	left, right := *a, *b
+	left.CreatedAt, left.UpdatedAt, left.Date = nil, nil, nil
+	right.CreatedAt, right.UpdatedAt, right.Date = nil, nil, nil
+	return left != right || !timeEqual(a.Date, b.Date)
+}
+
+// timeEqual returns true if both times are nil or the same instant
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// excludedSet returns the SET clause of an upsert, updating the fields
+// other than the keys with the values of the conflicting insert
+func excludedSet(fields, keys []string) string {
+	var set []string
+	for _, field := range fields {
+		if !containsString(keys, field) {
+			set = append(set, field+"=excluded."+field)
+		}
 	}
+	return strings.Join(set, ", ")
+}
 
-	query := article.Insert(model.WithStatement("INSERT OR REPLACE INTO"))
+// containsString returns true if value is in list
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteArticle removes an article by slug
+func DeleteArticle(ctx context.Context, db *sqlx.DB, slug string) error {
+	var article *model.Article
+	query := article.Delete(model.WithWhere("slug=?"))
 
-	_, err := db.NamedExecContext(ctx, query, article)
+	_, err := db.ExecContext(ctx, query, slug)
 
 	return err
 }
 
+// DeleteArticleFile removes the articles sourced from a file
+func DeleteArticleFile(ctx context.Context, db *sqlx.DB, filename string) error {
+	var article *model.Article
+	query := article.Delete(model.WithWhere("filename=?"))
+
+	_, err := db.ExecContext(ctx, query, filename)
+
+	return err
+}
+
+// GetArticleFiles returns the file names of all stored articles, including
+// drafts and unlisted articles
+func GetArticleFiles(ctx context.Context, db *sqlx.DB) ([]string, error) {
+	var article *model.Article
+	query := article.Select(model.WithColumns([]string{"DISTINCT filename"}), model.WithOrderBy("filename"))
+
+	var filenames []string
+	err := db.SelectContext(ctx, &filenames, query)
+	return filenames, err
+}
+
 // CountArticles returns the total number of articles
-func CountArticles(ctx context.Context, db *sqlx.DB) (int, error) {
+func CountArticles(ctx context.Context, db *sqlx.DB, visibility Visibility) (int, error) {
+	return CountFilteredArticles(ctx, db, visibility, ArticleFilter{})
+}
+
+// CountFilteredArticles returns the count of articles matching filter
+func CountFilteredArticles(ctx context.Context, db *sqlx.DB, visibility Visibility, filter ArticleFilter) (int, error) {
 	var count int
-	err := db.GetContext(ctx, &count, "SELECT COUNT(*) FROM article")
+	var article *model.Article
+	clause, filterArgs := filter.Where()
+	where, args := visibility.Where("", clause)
+	query := article.Select(model.WithColumns([]string{"COUNT(*)"}), model.WithWhere(where))
+
+	err := db.GetContext(ctx, &count, query, append(filterArgs, args...)...)
 	return count, err
 }
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// Article sources accepted by ArticleFilter
+const (
+	// SourceLocal matches articles published on the blog
+	SourceLocal = "local"
+
+	// SourceExternal matches articles linking to an external source
+	SourceExternal = "external"
+)
+
+// ArticleFilter narrows down article lists. The zero value matches all articles.
+type ArticleFilter struct {
+	// Year limits articles to a calendar year, 0 matches any year
+	Year int
+
+	// Month limits articles to a month of Year (1-12), 0 matches any month
+	Month int
+
+	// Source is SourceLocal, SourceExternal or empty for both
+	Source string
+}
+
+// Where returns the conditions and arguments for the filter
+func (f ArticleFilter) Where() (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if f.Year > 0 {
+		from := time.Date(f.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(1, 0, 0)
+		if f.Month > 0 {
+			from = time.Date(f.Year, time.Month(f.Month), 1, 0, 0, 0, 0, time.UTC)
+			to = from.AddDate(0, 1, 0)
+		}
+		conditions = append(conditions, "date >= ?", "date < ?")
+		args = append(args, from, to)
+	}
+
+	switch f.Source {
+	case SourceLocal:
+		conditions = append(conditions, "COALESCE(source, '') = ''")
+	case SourceExternal:
+		conditions = append(conditions, "COALESCE(source, '') != ''")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
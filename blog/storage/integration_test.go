@@ -138,7 +138,7 @@ func TestStorageIntegration_ConcurrentInserts(t *testing.T) {
 	require.Equal(t, 10, finalCount)
 }
 
-// TestStorageIntegration_ReplaceOnDuplicate tests that INSERT OR REPLACE works correctly
+// TestStorageIntegration_ReplaceOnDuplicate tests that an article with a taken slug replaces the stored one
 func TestStorageIntegration_ReplaceOnDuplicate(t *testing.T) {
 	db := setupTestDB(t)
 	defer cleanupTestDB(t, db)
@@ -176,6 +176,100 @@ func TestStorageIntegration_ReplaceOnDuplicate(t *testing.T) {
 	require.Equal(t, "Replaced Article", retrieved.Title)
 }
 
+// TestStorageIntegration_Upsert tests that updates keep the article identity and created time
+func TestStorageIntegration_Upsert(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	article := &model.Article{
+		ID:          "upsert",
+		Slug:        "first-slug",
+		Title:       "Upsert",
+		Filename:    "data/upsert.md",
+		Date:        timePtr(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)),
+		ContentHash: "one",
+	}
+	require.NoError(t, storage.InsertArticle(ctx, article))
+	require.NoError(t, storage.SetArticleTags(ctx, "upsert", []string{"Go"}))
+
+	stored, err := storage.GetArticleBySlug(ctx, "first-slug")
+	require.NoError(t, err)
+
+	t.Run("unchanged article keeps timestamps", func(t *testing.T) {
+		again := *article
+		again.CreatedAt, again.UpdatedAt = nil, nil
+		require.NoError(t, storage.InsertArticle(ctx, &again))
+
+		got, err := storage.GetArticleBySlug(ctx, "first-slug")
+		require.NoError(t, err)
+		require.True(t, stored.CreatedAt.Equal(*got.CreatedAt))
+		require.True(t, stored.UpdatedAt.Equal(*got.UpdatedAt))
+	})
+
+	t.Run("changed article bumps updated time", func(t *testing.T) {
+		time.Sleep(10 * time.Millisecond)
+
+		changed := *article
+		changed.ContentHash = "two"
+		require.NoError(t, storage.InsertArticle(ctx, &changed))
+
+		got, err := storage.GetArticleBySlug(ctx, "first-slug")
+		require.NoError(t, err)
+		require.True(t, stored.CreatedAt.Equal(*got.CreatedAt))
+		require.True(t, got.UpdatedAt.After(*stored.UpdatedAt))
+
+		tags, err := storage.GetArticleTags(ctx, "upsert")
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+	})
+
+	t.Run("renamed article keeps previous slug", func(t *testing.T) {
+		renamed := *article
+		renamed.Slug = "second-slug"
+		require.NoError(t, storage.InsertArticle(ctx, &renamed))
+
+		_, err := storage.GetArticleBySlug(ctx, "first-slug")
+		require.Error(t, err)
+
+		got, err := storage.GetArticleByPreviousSlug(ctx, "first-slug")
+		require.NoError(t, err)
+		require.Equal(t, "second-slug", got.Slug)
+		require.True(t, stored.CreatedAt.Equal(*got.CreatedAt))
+
+		count, err := storage.CountArticles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("reused slug no longer redirects", func(t *testing.T) {
+		other := &model.Article{
+			ID:       "other",
+			Slug:     "first-slug",
+			Title:    "Other",
+			Filename: "data/other.md",
+			Date:     timeNow(),
+		}
+		require.NoError(t, storage.InsertArticle(ctx, other))
+
+		_, err := storage.GetArticleByPreviousSlug(ctx, "first-slug")
+		require.Error(t, err)
+	})
+
+	t.Run("deleted file removes the article", func(t *testing.T) {
+		require.NoError(t, storage.DeleteArticleFile(ctx, "data/upsert.md"))
+
+		_, err := storage.GetArticleBySlug(ctx, "second-slug")
+		require.Error(t, err)
+
+		count, err := storage.CountArticles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+}
+
 // TestStorageIntegration_SearchAccuracy tests search result accuracy
 func TestStorageIntegration_SearchAccuracy(t *testing.T) {
 	db := setupTestDB(t)
@@ -209,9 +303,18 @@ func TestStorageIntegration_SearchAccuracy(t *testing.T) {
 		},
 	}
 
+	bodies := map[string]string{
+		"python-basics":  "Variables, loops and list comprehensions. Goroutines are not a thing here.",
+		"go-performance": "Profile with pprof, reduce allocations and benchmark your goroutines.",
+		"rust-safety":    "The borrow checker enforces ownership rules at compile time.",
+	}
+
 	for i := range articles {
 		err := storage.InsertArticle(ctx, &articles[i])
 		require.NoError(t, err)
+
+		err = storage.IndexArticleBody(ctx, articles[i].Slug, bodies[articles[i].Slug])
+		require.NoError(t, err)
 	}
 
 	tests := []struct {
@@ -220,6 +323,35 @@ func TestStorageIntegration_SearchAccuracy(t *testing.T) {
 		expectedCount int
 		expectedSlugs []string
 	}{
+		{
+			name:          "search by body",
+			query:         "borrow checker",
+			expectedCount: 1,
+			expectedSlugs: []string{"rust-safety"},
+		},
+		{
+			name:          "search by body phrase",
+			query:         `"compile time"`,
+			expectedCount: 1,
+			expectedSlugs: []string{"rust-safety"},
+		},
+		{
+			name:          "phrase must be adjacent",
+			query:         `"checker borrow"`,
+			expectedCount: 0,
+		},
+		{
+			name:          "search by prefix",
+			query:         "alloc*",
+			expectedCount: 1,
+			expectedSlugs: []string{"go-performance"},
+		},
+		{
+			name:          "query with punctuation",
+			query:         "Rust's (safety)",
+			expectedCount: 1,
+			expectedSlugs: []string{"rust-safety"},
+		},
 		{
 			name:          "search by title",
 			query:         "Python",
@@ -272,6 +404,72 @@ func TestStorageIntegration_SearchAccuracy(t *testing.T) {
 	}
 }
 
+// TestStorageIntegration_SearchRanking tests that stronger matches rank first
+// and that matches are highlighted in the snippet
+func TestStorageIntegration_SearchRanking(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	articles := []model.Article{
+		{
+			ID:    "rank-1",
+			Slug:  "in-body",
+			Title: "Concurrency Notes",
+			Date:  timePtr(time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			ID:    "rank-2",
+			Slug:  "in-title",
+			Title: "Goroutines Explained",
+			Date:  timePtr(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	for i := range articles {
+		require.NoError(t, storage.InsertArticle(ctx, &articles[i]))
+	}
+	require.NoError(t, storage.IndexArticleBody(ctx, "in-body", "A short aside on goroutines and channels."))
+	require.NoError(t, storage.IndexArticleBody(ctx, "in-title", "Everything you need to know."))
+
+	results, err := storage.SearchArticles(ctx, "goroutines")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// A title match outranks a body match, even for an older article
+	require.Equal(t, "in-title", results[0].Slug)
+	require.Equal(t, "in-body", results[1].Slug)
+	require.LessOrEqual(t, results[0].Rank, results[1].Rank)
+	require.Contains(t, results[1].Snippet, "<mark>goroutines</mark>")
+
+	// Reinserting article metadata keeps the index in sync
+	articles[0].Title = "Renamed"
+	require.NoError(t, storage.InsertArticle(ctx, &articles[0]))
+	results, err = storage.SearchArticles(ctx, "Renamed")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	// Updating article metadata keeps the indexed body
+	results, err = storage.SearchArticles(ctx, "channels")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	// Deleted articles are removed from the index
+	require.NoError(t, storage.DeleteArticle(ctx, "in-title"))
+	results, err = storage.SearchArticles(ctx, "goroutines")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "in-body", results[0].Slug)
+
+	// The indexed text isn't HTML, so code samples in the snippet are escaped
+	require.NoError(t, storage.IndexArticleBody(ctx, "in-body", `Spawn with <script>go()</script> & "friends".`))
+	results, err = storage.SearchArticles(ctx, "spawn")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "<mark>Spawn</mark> with &lt;script&gt;go()&lt;/script&gt; &amp; &#34;friends&#34;.", results[0].Snippet)
+}
+
 // TestStorageIntegration_DateOrdering tests proper date-based ordering
 func TestStorageIntegration_DateOrdering(t *testing.T) {
 	db := setupTestDB(t)
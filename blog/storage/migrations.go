@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/titpetric/platform-example/blog/model"
+	"github.com/titpetric/platform-example/blog/schema"
+)
+
+// migrationsSchema creates the table recording applied migrations
+const migrationsSchema = `CREATE TABLE IF NOT EXISTS migrations (
+    project TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    statement_index INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT '',
+    checksum TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (project, filename)
+)`
+
+// legacyMigrations rebuilds a migrations table recorded by earlier versions
+// of mig, keyed on the project alone and without checksums. Its rows are
+// kept, with an empty checksum until Migrate checks them.
+var legacyMigrations = []string{
+	"ALTER TABLE migrations RENAME TO migrations_legacy",
+	migrationsSchema,
+	"INSERT INTO migrations (project, filename, statement_index, status) SELECT project, filename, statement_index, status FROM migrations_legacy",
+	"DROP TABLE migrations_legacy",
+}
+
+// migrationApplied is the status of a migration with all statements applied
+const migrationApplied = "ok"
+
+// GetMigrations retrieves the migrations applied for a project, in the order they were applied
+func GetMigrations(ctx context.Context, db *sqlx.DB, project string) ([]model.Migrations, error) {
+	if err := upgradeMigrations(ctx, db); err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, migrationsSchema); err != nil {
+		return nil, err
+	}
+
+	var result []model.Migrations
+	query := (&model.Migrations{}).Select(model.WithWhere("project=?"), model.WithOrderBy("filename"))
+
+	if err := db.SelectContext(ctx, &result, query, project); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// upgradeMigrations rebuilds the migrations table if it was recorded by
+// earlier versions of mig, and does nothing otherwise
+func upgradeMigrations(ctx context.Context, db *sqlx.DB) error {
+	var columns []string
+	if err := db.SelectContext(ctx, &columns, "SELECT name FROM pragma_table_info('migrations')"); err != nil {
+		return err
+	}
+	if len(columns) == 0 || slices.Contains(columns, "checksum") {
+		return nil
+	}
+
+	return inTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		for _, statement := range legacyMigrations {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("upgrading migrations table: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Migrate applies the migrations of a project that haven't been applied yet.
+// Each migration runs in a transaction together with its record in the
+// migrations table. It refuses to apply anything if an applied migration
+// was changed or is unknown, and returns the number of migrations applied.
+//
+// Migrations recorded without a checksum by earlier versions of mig
+// continue from their statement index, and are then recorded as applied.
+func Migrate(ctx context.Context, db *sqlx.DB, project string, migrations []schema.Migration) (int, error) {
+	applied, err := GetMigrations(ctx, db, project)
+	if err != nil {
+		return 0, err
+	}
+
+	done := make(map[string]bool, len(applied))
+	var legacy []model.Migrations
+	for _, record := range applied {
+		migration, ok := findMigration(migrations, record.Filename)
+		if !ok {
+			return 0, fmt.Errorf("%s: applied migration %s is unknown", project, record.Filename)
+		}
+		done[record.Filename] = true
+		if record.Checksum == "" {
+			legacy = append(legacy, record)
+			continue
+		}
+		if record.Checksum != migration.Checksum() {
+			return 0, fmt.Errorf("%s: migration %s was changed after it was applied", project, record.Filename)
+		}
+	}
+
+	for _, record := range legacy {
+		migration, _ := findMigration(migrations, record.Filename)
+		if err := applyMigration(ctx, db, project, migration, int(record.StatementIndex)); err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	for _, migration := range migrations {
+		if done[migration.Name] {
+			continue
+		}
+		if err := applyMigration(ctx, db, project, migration, 0); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Rollback reverts the last steps applied migrations of a project with
+// their down statements, and returns the number of migrations reverted.
+func Rollback(ctx context.Context, db *sqlx.DB, project string, migrations []schema.Migration, steps int) (int, error) {
+	applied, err := GetMigrations(ctx, db, project)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for i := len(applied) - 1; i >= 0 && count < steps; i-- {
+		migration, ok := findMigration(migrations, applied[i].Filename)
+		if !ok {
+			return count, fmt.Errorf("%s: applied migration %s is unknown", project, applied[i].Filename)
+		}
+		if migration.Down == "" {
+			return count, fmt.Errorf("%s: migration %s can't be rolled back", project, migration.Name)
+		}
+		if err := revertMigration(ctx, db, project, migration); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// applyMigration runs the statements of a migration starting from the
+// statement index from, and records it
+func applyMigration(ctx context.Context, db *sqlx.DB, project string, migration schema.Migration, from int) error {
+	statements := schema.Statements(migration.SQL)
+	record := &model.Migrations{
+		Project:        project,
+		Filename:       migration.Name,
+		StatementIndex: int64(len(statements)),
+		Status:         migrationApplied,
+		Checksum:       migration.Checksum(),
+	}
+
+	return inTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		for i := from; i < len(statements); i++ {
+			if _, err := tx.ExecContext(ctx, statements[i]); err != nil {
+				return fmt.Errorf("%s: statement %d: %w", migration.Name, i+1, err)
+			}
+		}
+
+		_, err := tx.NamedExecContext(ctx, record.Insert(model.WithStatement("INSERT OR REPLACE INTO")), record)
+		return err
+	})
+}
+
+// revertMigration runs the down statements of a migration and removes its record
+func revertMigration(ctx context.Context, db *sqlx.DB, project string, migration schema.Migration) error {
+	return inTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		for i, statement := range schema.Statements(migration.Down) {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("%s: down statement %d: %w", migration.Name, i+1, err)
+			}
+		}
+
+		query := (&model.Migrations{}).Delete(model.WithWhere("project=? AND filename=?"))
+		_, err := tx.ExecContext(ctx, query, project, migration.Name)
+		return err
+	})
+}
+
+// inTransaction runs fn in a transaction, committing it if fn succeeds
+func inTransaction(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findMigration returns the migration with the file name
+func findMigration(migrations []schema.Migration, name string) (schema.Migration, bool) {
+	for _, migration := range migrations {
+		if migration.Name == name {
+			return migration, true
+		}
+	}
+	return schema.Migration{}, false
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/schema"
+)
+
+func openMigrationTestDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Open("sqlite", t.TempDir()+"/test.db")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestMigrate tests applying, recording and rolling back migrations
+func TestMigrate(t *testing.T) {
+	db := openMigrationTestDB(t)
+	ctx := context.Background()
+	migrations := schema.Migrations()
+
+	count, err := Migrate(ctx, db, "blog", migrations)
+	require.NoError(t, err)
+	require.Equal(t, len(migrations), count)
+
+	t.Run("records applied migrations", func(t *testing.T) {
+		applied, err := GetMigrations(ctx, db, "blog")
+		require.NoError(t, err)
+		require.Len(t, applied, len(migrations))
+		for i, record := range applied {
+			require.Equal(t, migrations[i].Name, record.Filename)
+			require.Equal(t, migrations[i].Checksum(), record.Checksum)
+			require.Equal(t, int64(len(schema.Statements(migrations[i].SQL))), record.StatementIndex)
+		}
+
+		other, err := GetMigrations(ctx, db, "other")
+		require.NoError(t, err)
+		require.Empty(t, other)
+	})
+
+	t.Run("applies nothing twice", func(t *testing.T) {
+		count, err := Migrate(ctx, db, "blog", migrations)
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("rolls back and applies again", func(t *testing.T) {
+		count, err := Rollback(ctx, db, "blog", migrations, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		applied, err := GetMigrations(ctx, db, "blog")
+		require.NoError(t, err)
+		require.Len(t, applied, len(migrations)-1)
+
+		count, err = Migrate(ctx, db, "blog", migrations)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("rolls back everything", func(t *testing.T) {
+		count, err := Rollback(ctx, db, "blog", migrations, len(migrations)+1)
+		require.NoError(t, err)
+		require.Equal(t, len(migrations), count)
+
+		_, err = db.ExecContext(ctx, "SELECT id FROM article")
+		require.Error(t, err)
+
+		count, err = Migrate(ctx, db, "blog", migrations)
+		require.NoError(t, err)
+		require.Equal(t, len(migrations), count)
+	})
+}
+
+// TestMigrateDrift tests that changed or unknown applied migrations are refused
+func TestMigrateDrift(t *testing.T) {
+	ctx := context.Background()
+	migrations := []schema.Migration{
+		{Name: "test.up.sql", SQL: "CREATE TABLE t (id TEXT);", Down: "DROP TABLE t;"},
+		{Name: "test_002.up.sql", SQL: "ALTER TABLE t ADD COLUMN name TEXT;"},
+	}
+
+	t.Run("changed migration", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		_, err := Migrate(ctx, db, "test", migrations[:1])
+		require.NoError(t, err)
+
+		changed := []schema.Migration{
+			{Name: "test.up.sql", SQL: "CREATE TABLE t (id INTEGER);"},
+			migrations[1],
+		}
+		count, err := Migrate(ctx, db, "test", changed)
+		require.ErrorContains(t, err, "test.up.sql was changed")
+		require.Zero(t, count)
+
+		_, err = db.ExecContext(ctx, "SELECT name FROM t")
+		require.Error(t, err, "pending migrations must not be applied")
+	})
+
+	t.Run("unknown migration", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		_, err := Migrate(ctx, db, "test", migrations)
+		require.NoError(t, err)
+
+		_, err = Migrate(ctx, db, "test", migrations[:1])
+		require.ErrorContains(t, err, "test_002.up.sql is unknown")
+	})
+
+	t.Run("failed migration is not recorded", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		broken := []schema.Migration{
+			{Name: "test.up.sql", SQL: "CREATE TABLE t (id TEXT);\nINSERT INTO missing VALUES (1);"},
+		}
+		_, err := Migrate(ctx, db, "test", broken)
+		require.ErrorContains(t, err, "statement 2")
+
+		applied, err := GetMigrations(ctx, db, "test")
+		require.NoError(t, err)
+		require.Empty(t, applied)
+
+		_, err = db.ExecContext(ctx, "SELECT id FROM t")
+		require.Error(t, err, "statements of a failed migration must be rolled back")
+	})
+
+	t.Run("migration without down", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		_, err := Migrate(ctx, db, "test", migrations)
+		require.NoError(t, err)
+
+		_, err = Rollback(ctx, db, "test", migrations, 1)
+		require.ErrorContains(t, err, "can't be rolled back")
+	})
+}
+
+// TestMigrateLegacy tests upgrading a database recorded by earlier versions
+// of mig, with a migrations table keyed on the project and no checksums
+func TestMigrateLegacy(t *testing.T) {
+	db := openMigrationTestDB(t)
+	ctx := context.Background()
+	migrations := schema.Migrations()
+
+	// The first statements of blog.up.sql are the schema mig applied
+	const legacyStatements = 5
+	_, err := db.ExecContext(ctx, `CREATE TABLE migrations (
+    project TEXT PRIMARY KEY,
+    filename TEXT NOT NULL,
+    statement_index INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT ''
+)`)
+	require.NoError(t, err)
+	for _, statement := range schema.Statements(migrations[0].SQL)[:legacyStatements] {
+		_, err := db.ExecContext(ctx, statement)
+		require.NoError(t, err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO migrations VALUES ('blog', 'blog.up.sql', ?, 'ok')", legacyStatements)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "INSERT INTO article (id, slug, title, filename, date, url) VALUES ('a', 'a', 'A', 'a.md', '2024-01-01', '/blog/a/')")
+	require.NoError(t, err)
+
+	count, err := Migrate(ctx, db, "blog", migrations)
+	require.NoError(t, err)
+	require.Equal(t, len(migrations)-1, count)
+
+	applied, err := GetMigrations(ctx, db, "blog")
+	require.NoError(t, err)
+	require.Len(t, applied, len(migrations))
+	for i, record := range applied {
+		require.Equal(t, migrations[i].Name, record.Filename)
+		require.Equal(t, migrations[i].Checksum(), record.Checksum)
+		require.Equal(t, migrationApplied, record.Status)
+	}
+
+	var title string
+	require.NoError(t, db.GetContext(ctx, &title, "SELECT title FROM article WHERE id='a' AND draft=0"))
+	require.Equal(t, "A", title)
+
+	// Statements added to blog.up.sql after mig applied it
+	_, err = db.ExecContext(ctx, "SELECT hash FROM render_cache")
+	require.NoError(t, err)
+
+	count, err = Migrate(ctx, db, "blog", migrations)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// RedirectPath normalizes the path of a redirect. A bare slug refers to a
+// blog article, other paths get a leading slash and lose the trailing one,
+// so `/old/` and `/old` are the same redirect.
+func RedirectPath(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if !strings.Contains(value, "/") {
+		value = "/blog/" + value
+	}
+	return path.Clean("/" + value)
+}
+
+// SetArticleRedirects replaces the redirects from the previous paths of an article to target
+func SetArticleRedirects(ctx context.Context, db *sqlx.DB, articleID, target string, paths []string) error {
+	return setRedirects(ctx, db, articleID, redirectsTo(target, paths))
+}
+
+// SetSiteRedirects replaces the site redirects, which don't belong to an article.
+// Redirects maps the redirected path to the target.
+func SetSiteRedirects(ctx context.Context, db *sqlx.DB, redirects map[string]string) error {
+	return setRedirects(ctx, db, "", redirects)
+}
+
+// setRedirects replaces the redirects of an article, or the site redirects if articleID is empty.
+// A path redirected by another article or the site is taken over.
+func setRedirects(ctx context.Context, db *sqlx.DB, articleID string, redirects map[string]string) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var redirect *model.Redirect
+	if _, err := tx.ExecContext(ctx, redirect.Delete(model.WithWhere("article_id=?")), articleID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for from, target := range redirects {
+		redirect := &model.Redirect{
+			Path:      RedirectPath(from),
+			Target:    strings.TrimSpace(target),
+			ArticleID: articleID,
+		}
+		// Skip empty redirects and redirects to the same path
+		if redirect.Path == "" || redirect.Target == "" || RedirectPath(redirect.Target) == redirect.Path {
+			continue
+		}
+		redirect.SetCreatedAt(now)
+
+		if _, err := tx.NamedExecContext(ctx, redirect.Insert(model.WithStatement("INSERT OR REPLACE INTO")), redirect); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// redirectsTo maps each of paths to target
+func redirectsTo(target string, paths []string) map[string]string {
+	result := make(map[string]string, len(paths))
+	for _, path := range paths {
+		result[path] = target
+	}
+	return result
+}
+
+// GetRedirect retrieves the redirect for a path. Redirects to articles
+// which aren't visible aren't returned.
+func GetRedirect(ctx context.Context, db *sqlx.DB, visibility Visibility, path string) (*model.Redirect, error) {
+	var redirect model.Redirect
+	where, args := redirectVisibility(visibility, "path=?")
+	query := redirect.Select(model.WithWhere(where), model.WithLimit(0, 1))
+
+	err := db.GetContext(ctx, &redirect, query, append([]any{RedirectPath(path)}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redirect, nil
+}
+
+// GetRedirects retrieves all redirects ordered by path, including the
+// previous slugs of renamed articles. Redirects to articles which aren't
+// visible are left out.
+func GetRedirects(ctx context.Context, db *sqlx.DB, visibility Visibility) ([]model.Redirect, error) {
+	var result []model.Redirect
+	where, args := redirectVisibility(visibility, "")
+	query := (&model.Redirect{}).Select(model.WithWhere(where))
+
+	if err := db.SelectContext(ctx, &result, query, args...); err != nil {
+		return nil, err
+	}
+
+	// Previous slugs of renamed articles redirect to the article
+	var renamed []model.Redirect
+	where, args = visibility.Where("article.", "")
+	query = "SELECT '/blog/' || article_slug.slug AS path, article.url AS target, article.id AS article_id, article_slug.created_at AS created_at" +
+		" FROM article_slug INNER JOIN article ON article.id = article_slug.article_id"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if err := db.SelectContext(ctx, &renamed, query, args...); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(result))
+	for _, redirect := range result {
+		seen[redirect.Path] = true
+	}
+	for _, redirect := range renamed {
+		if !seen[redirect.Path] {
+			result = append(result, redirect)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// redirectVisibility combines clause with a condition leaving out the
+// redirects of articles which aren't visible
+func redirectVisibility(visibility Visibility, clause string) (string, []any) {
+	articles := "SELECT id FROM article"
+	where, args := visibility.Where("", "")
+	if where != "" {
+		articles += " WHERE " + where
+	}
+
+	condition := "(article_id='' OR article_id IN (" + articles + "))"
+	if clause != "" {
+		condition = clause + " AND " + condition
+	}
+	return condition, args
+}
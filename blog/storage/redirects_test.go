@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+func TestRedirectPath(t *testing.T) {
+	tests := map[string]string{
+		"":              "",
+		"old-slug":      "/blog/old-slug",
+		"/old/path/":    "/old/path",
+		"old/path":      "/old/path",
+		"/../etc":       "/etc",
+		" /spaced.html": "/spaced.html",
+	}
+	for input, want := range tests {
+		require.Equal(t, want, RedirectPath(input), input)
+	}
+}
+
+// TestStorageIntegration_Redirects tests article aliases and site redirects
+func TestStorageIntegration_Redirects(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	past := timePtr(time.Now().Add(-time.Hour))
+	articles := []model.Article{
+		{ID: "published", Slug: "published", Title: "Published", URL: "/blog/published/", Date: past},
+		{ID: "draft", Slug: "draft", Title: "Draft", URL: "/blog/draft/", Date: past, Draft: true},
+	}
+	for i := range articles {
+		require.NoError(t, storage.InsertArticle(ctx, &articles[i]))
+	}
+
+	require.NoError(t, storage.SetArticleRedirects(ctx, "published", "/blog/published/", []string{"old-published", "/2019/01/published.html", "/blog/published/"}))
+	require.NoError(t, storage.SetArticleRedirects(ctx, "draft", "/blog/draft/", []string{"old-draft"}))
+	require.NoError(t, storage.SetSiteRedirects(ctx, map[string]string{"/about/": "/resume/"}))
+
+	t.Run("lookup", func(t *testing.T) {
+		redirect, err := storage.GetRedirect(ctx, "/blog/old-published/")
+		require.NoError(t, err)
+		require.Equal(t, "/blog/published/", redirect.Target)
+
+		redirect, err = storage.GetRedirect(ctx, "/about")
+		require.NoError(t, err)
+		require.Equal(t, "/resume/", redirect.Target)
+		require.Empty(t, redirect.ArticleID)
+
+		_, err = storage.GetRedirect(ctx, "/blog/published")
+		require.Error(t, err, "an article must not redirect to itself")
+
+		_, err = storage.GetRedirect(ctx, "/blog/old-draft")
+		require.Error(t, err, "drafts must not be revealed")
+	})
+
+	t.Run("list includes renamed slugs", func(t *testing.T) {
+		renamed := articles[0]
+		renamed.Slug, renamed.URL = "renamed", "/blog/renamed/"
+		require.NoError(t, storage.InsertArticle(ctx, &renamed))
+
+		redirects, err := storage.GetRedirects(ctx)
+		require.NoError(t, err)
+
+		paths := make(map[string]string)
+		for _, redirect := range redirects {
+			paths[redirect.Path] = redirect.Target
+		}
+		require.Equal(t, map[string]string{
+			"/2019/01/published.html": "/blog/published/",
+			"/about":                  "/resume/",
+			"/blog/old-published":     "/blog/published/",
+			"/blog/published":         "/blog/renamed/",
+		}, paths)
+	})
+
+	t.Run("replaced and removed", func(t *testing.T) {
+		require.NoError(t, storage.SetSiteRedirects(ctx, nil))
+		_, err := storage.GetRedirect(ctx, "/about")
+		require.Error(t, err)
+
+		redirect, err := storage.GetRedirect(ctx, "/blog/old-published")
+		require.NoError(t, err, "site redirects don't replace article aliases")
+		require.Equal(t, "published", redirect.ArticleID)
+
+		require.NoError(t, storage.DeleteArticle(ctx, "renamed"))
+		_, err = storage.GetRedirect(ctx, "/blog/old-published")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// GetRenderCache retrieves cached HTML by content hash
+func GetRenderCache(ctx context.Context, db *sqlx.DB, hash string) (*model.RenderCache, error) {
+	var entry model.RenderCache
+	query := entry.Select(model.WithWhere("hash=?"))
+
+	if err := db.GetContext(ctx, &entry, query, hash); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// SaveRenderCache stores rendered HTML by content hash
+func SaveRenderCache(ctx context.Context, db *sqlx.DB, entry *model.RenderCache) error {
+	entry.SetCreatedAt(time.Now())
+
+	query := entry.Insert(model.WithStatement("INSERT OR REPLACE INTO"))
+
+	_, err := db.NamedExecContext(ctx, query, entry)
+
+	return err
+}
+
+// PruneRenderCache removes the cached HTML of all hashes except keep, and
+// returns the number of removed entries
+func PruneRenderCache(ctx context.Context, db *sqlx.DB, keep []string) (int64, error) {
+	var entry *model.RenderCache
+	query := entry.Delete()
+
+	args := make([]any, 0, len(keep))
+	for _, hash := range keep {
+		args = append(args, hash)
+	}
+	if len(keep) > 0 {
+		query = entry.Delete(model.WithWhere("hash NOT IN (?" + strings.Repeat(", ?", len(keep)-1) + ")"))
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
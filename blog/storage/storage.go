@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -12,6 +13,12 @@ import (
 // Storage provides database operations for the blog module
 type Storage struct {
 	db *sqlx.DB
+
+	// preview includes drafts and scheduled articles in queries
+	preview bool
+
+	// unlisted includes unlisted articles in lists
+	unlisted bool
 }
 
 // NewStorage creates a new Storage instance
@@ -19,33 +26,168 @@ func NewStorage(db *sqlx.DB) *Storage {
 	return &Storage{db: db}
 }
 
+// SetPreview enables or disables preview mode, which makes drafts and
+// scheduled articles visible
+func (s *Storage) SetPreview(preview bool) {
+	s.preview = preview
+}
+
+// WithUnlisted returns a copy of the storage that includes unlisted articles in lists
+func (s *Storage) WithUnlisted() *Storage {
+	result := *s
+	result.unlisted = true
+	return &result
+}
+
+// visibility returns the visibility for a query, listed is true for lists, feeds and search
+func (s *Storage) visibility(listed bool) Visibility {
+	return Visibility{
+		Preview: s.preview,
+		Listed:  listed && !s.unlisted,
+		Now:     time.Now(),
+	}
+}
+
 // GetArticleBySlug retrieves an article by its slug
 func (s *Storage) GetArticleBySlug(ctx context.Context, slug string) (*model.Article, error) {
-	return GetArticleBySlug(ctx, s.db, slug)
+	return GetArticleBySlug(ctx, s.db, s.visibility(false), slug)
+}
+
+// GetArticleByURL retrieves an article by its URL path
+func (s *Storage) GetArticleByURL(ctx context.Context, url string) (*model.Article, error) {
+	return GetArticleByURL(ctx, s.db, s.visibility(false), url)
+}
+
+// GetArticleByPreviousSlug retrieves the article that was renamed from slug
+func (s *Storage) GetArticleByPreviousSlug(ctx context.Context, slug string) (*model.Article, error) {
+	return GetArticleByPreviousSlug(ctx, s.db, s.visibility(false), slug)
 }
 
 // GetArticles retrieves all articles
 func (s *Storage) GetArticles(ctx context.Context, start, length int) ([]model.Article, error) {
-	return GetArticles(ctx, s.db, start, length)
+	return GetArticles(ctx, s.db, s.visibility(true), start, length)
+}
+
+// FilterArticles retrieves articles matching a filter
+func (s *Storage) FilterArticles(ctx context.Context, filter ArticleFilter, start, length int) ([]model.Article, error) {
+	return FilterArticles(ctx, s.db, s.visibility(true), filter, start, length)
 }
 
 // SearchArticles performs a full-text search on articles
-func (s *Storage) SearchArticles(ctx context.Context, query string) ([]model.Article, error) {
-	return SearchArticles(ctx, s.db, query)
+func (s *Storage) SearchArticles(ctx context.Context, query string) ([]model.SearchResult, error) {
+	return SearchArticles(ctx, s.db, s.visibility(true), query)
 }
 
-// InsertArticle inserts a new article
+// IndexArticleBody stores the plain text article body for full-text search
+func (s *Storage) IndexArticleBody(ctx context.Context, slug string, body string) error {
+	return IndexArticleBody(ctx, s.db, slug, body)
+}
+
+// InsertArticle inserts or updates an article
 func (s *Storage) InsertArticle(ctx context.Context, article *model.Article) error {
 	return InsertArticle(ctx, s.db, article)
 }
 
+// DeleteArticle removes an article by slug
+func (s *Storage) DeleteArticle(ctx context.Context, slug string) error {
+	return DeleteArticle(ctx, s.db, slug)
+}
+
+// DeleteArticleFile removes the articles sourced from a file
+func (s *Storage) DeleteArticleFile(ctx context.Context, filename string) error {
+	return DeleteArticleFile(ctx, s.db, filename)
+}
+
+// GetArticleFiles returns the file names of all stored articles
+func (s *Storage) GetArticleFiles(ctx context.Context) ([]string, error) {
+	return GetArticleFiles(ctx, s.db)
+}
+
 // CountArticles returns the total count of articles
 func (s *Storage) CountArticles(ctx context.Context) (int, error) {
-	return CountArticles(ctx, s.db)
+	return CountArticles(ctx, s.db, s.visibility(true))
+}
+
+// CountFilteredArticles returns the count of articles matching a filter
+func (s *Storage) CountFilteredArticles(ctx context.Context, filter ArticleFilter) (int, error) {
+	return CountFilteredArticles(ctx, s.db, s.visibility(true), filter)
 }
 
-// InitSchema initializes the database schema from embedded schema
+// SetArticleTags replaces the tags assigned to an article
+func (s *Storage) SetArticleTags(ctx context.Context, articleID string, names []string) error {
+	return SetArticleTags(ctx, s.db, articleID, names)
+}
+
+// GetTag retrieves a tag by its slug
+func (s *Storage) GetTag(ctx context.Context, slug string) (*model.Tag, error) {
+	return GetTag(ctx, s.db, slug)
+}
+
+// GetTags retrieves all tags with article counts
+func (s *Storage) GetTags(ctx context.Context) ([]model.TagCount, error) {
+	return GetTags(ctx, s.db, s.visibility(true))
+}
+
+// GetArticleTags retrieves the tags of an article
+func (s *Storage) GetArticleTags(ctx context.Context, articleID string) ([]model.Tag, error) {
+	return GetArticleTags(ctx, s.db, articleID)
+}
+
+// GetArticlesByTag retrieves articles with a tag
+func (s *Storage) GetArticlesByTag(ctx context.Context, slug string, start, length int) ([]model.Article, error) {
+	return GetArticlesByTag(ctx, s.db, s.visibility(true), slug, start, length)
+}
+
+// SetArticleRedirects replaces the redirects from the previous paths of an article to target
+func (s *Storage) SetArticleRedirects(ctx context.Context, articleID, target string, paths []string) error {
+	return SetArticleRedirects(ctx, s.db, articleID, target, paths)
+}
+
+// SetSiteRedirects replaces the site redirects, mapping the redirected path to the target
+func (s *Storage) SetSiteRedirects(ctx context.Context, redirects map[string]string) error {
+	return SetSiteRedirects(ctx, s.db, redirects)
+}
+
+// GetRedirect retrieves the redirect for a path
+func (s *Storage) GetRedirect(ctx context.Context, path string) (*model.Redirect, error) {
+	return GetRedirect(ctx, s.db, s.visibility(false), path)
+}
+
+// GetRedirects retrieves all redirects, including previous slugs of renamed articles
+func (s *Storage) GetRedirects(ctx context.Context) ([]model.Redirect, error) {
+	return GetRedirects(ctx, s.db, s.visibility(false))
+}
+
+// GetRenderCache retrieves cached HTML by content hash
+func (s *Storage) GetRenderCache(ctx context.Context, hash string) (*model.RenderCache, error) {
+	return GetRenderCache(ctx, s.db, hash)
+}
+
+// SaveRenderCache stores rendered HTML by content hash
+func (s *Storage) SaveRenderCache(ctx context.Context, entry *model.RenderCache) error {
+	return SaveRenderCache(ctx, s.db, entry)
+}
+
+// PruneRenderCache removes the cached HTML of all hashes except keep
+func (s *Storage) PruneRenderCache(ctx context.Context, keep []string) (int64, error) {
+	return PruneRenderCache(ctx, s.db, keep)
+}
+
+// migrationProject is the project the blog migrations are recorded under
+const migrationProject = "blog"
+
+// InitSchema applies the blog schema migrations that haven't been applied yet
 func (s *Storage) InitSchema(ctx context.Context) error {
-	_, err := s.db.ExecContext(ctx, schema.InitialSchema)
+	_, err := Migrate(ctx, s.db, migrationProject, schema.Migrations())
 	return err
 }
+
+// Rollback reverts the last steps applied blog schema migrations
+func (s *Storage) Rollback(ctx context.Context, steps int) (int, error) {
+	return Rollback(ctx, s.db, migrationProject, schema.Migrations(), steps)
+}
+
+// GetMigrations retrieves the applied blog schema migrations
+func (s *Storage) GetMigrations(ctx context.Context) ([]model.Migrations, error) {
+	return GetMigrations(ctx, s.db, migrationProject)
+}
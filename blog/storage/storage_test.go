@@ -2,12 +2,15 @@ package storage
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/titpetric/platform-example/blog/markdown"
 	"github.com/titpetric/platform-example/blog/model"
 	"github.com/titpetric/platform-example/blog/schema"
 )
@@ -27,8 +30,8 @@ func setupTestDB(t *testing.T) *sqlx.DB {
 	db.SetMaxIdleConns(1)
 	db.SetMaxOpenConns(1)
 
-	// Initialize schema
-	_, err = db.Exec(schema.InitialSchema)
+	// Initialize schema, recording the applied migrations
+	err = NewStorage(db).InitSchema(context.Background())
 	if err != nil {
 		t.Fatalf("failed to initialize schema: %v", err)
 	}
@@ -86,6 +89,7 @@ func TestInsertArticle(t *testing.T) {
 		ID:          "test-1",
 		Slug:        "test-article",
 		Title:       "Test Article",
+		Date:        timeNow(),
 		Description: "This is a test article",
 		OgImage:     "/images/test.png",
 		Layout:      "post",
@@ -111,6 +115,12 @@ func TestInsertArticle(t *testing.T) {
 	if retrieved.Slug != "test-article" {
 		t.Errorf("expected slug 'test-article', got '%s'", retrieved.Slug)
 	}
+
+	// An article without a date can't be published or scheduled
+	undated := &model.Article{ID: "test-undated", Slug: "undated", Title: "Undated"}
+	if err := storage.InsertArticle(ctx, undated); err == nil {
+		t.Fatal("expected error for article without a date, got nil")
+	}
 }
 
 // TestGetArticleBySlug tests retrieving an article by slug
@@ -125,6 +135,7 @@ func TestGetArticleBySlug(t *testing.T) {
 		ID:          "test-2",
 		Slug:        "my-article",
 		Title:       "My Article",
+		Date:        timeNow(),
 		Description: "Description",
 		OgImage:     "/og.png",
 		Layout:      "post",
@@ -174,16 +185,19 @@ func TestGetArticles(t *testing.T) {
 			ID:    "test-3",
 			Slug:  "first",
 			Title: "First Article",
+			Date:  timeNow(),
 		},
 		{
 			ID:    "test-4",
 			Slug:  "second",
 			Title: "Second Article",
+			Date:  timeNow(),
 		},
 		{
 			ID:    "test-5",
 			Slug:  "third",
 			Title: "Third Article",
+			Date:  timeNow(),
 		},
 	}
 
@@ -224,18 +238,21 @@ func TestSearchArticles(t *testing.T) {
 			ID:          "test-6",
 			Slug:        "go-programming",
 			Title:       "Learning Go Programming",
+			Date:        timeNow(),
 			Description: "A comprehensive guide to Go",
 		},
 		{
 			ID:          "test-7",
 			Slug:        "rust-basics",
 			Title:       "Rust Basics",
+			Date:        timeNow(),
 			Description: "Introduction to Rust programming",
 		},
 		{
 			ID:          "test-8",
 			Slug:        "go-frameworks",
 			Title:       "Go Web Frameworks",
+			Date:        timeNow(),
 			Description: "Popular web frameworks in Go",
 		},
 	}
@@ -292,6 +309,7 @@ func TestCountArticles(t *testing.T) {
 			ID:    "test-" + string(rune(i)),
 			Slug:  "article-" + string(rune(i)),
 			Title: "Article",
+			Date:  timeNow(),
 		}
 		storage.InsertArticle(ctx, article)
 	}
@@ -319,6 +337,7 @@ func TestInsertArticleUpdate(t *testing.T) {
 		ID:    "test-update",
 		Slug:  "update-test",
 		Title: "Original Title",
+		Date:  timeNow(),
 	}
 
 	storage.InsertArticle(ctx, article)
@@ -354,6 +373,7 @@ func TestSchemaConstraints(t *testing.T) {
 		ID:    "test-constraint-1",
 		Slug:  "unique-slug",
 		Title: "Article 1",
+		Date:  timeNow(),
 	}
 
 	err := storage.InsertArticle(ctx, article1)
@@ -366,6 +386,7 @@ func TestSchemaConstraints(t *testing.T) {
 		ID:    "test-constraint-2",
 		Slug:  "unique-slug",
 		Title: "Article 2",
+		Date:  timeNow(),
 	}
 
 	err = storage.InsertArticle(ctx, article2)
@@ -402,6 +423,128 @@ func TestSearchArticlesEmpty(t *testing.T) {
 	}
 }
 
+// TestFTSQuery tests conversion of user input into FTS5 match expressions
+func TestFTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"go":                 `"go"`,
+		"go rust":            `"go" "rust"`,
+		"gorout*":            `"gorout"*`,
+		`"borrow checker"`:   `"borrow checker"`,
+		`"borrow check"* go`: `"borrow check"* "go"`,
+		`Rust's "unclosed`:   `"Rust's" "unclosed"`,
+		"*":                  "",
+		`a"b`:                `"ab"`,
+	}
+
+	for input, want := range tests {
+		if got := ftsQuery(input); got != want {
+			t.Errorf("ftsQuery(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// TestInitSchemaUpgrade tests that migrations upgrade a database created with the initial schema
+func TestInitSchemaUpgrade(t *testing.T) {
+	db, err := sqlx.Open("sqlite", t.TempDir()+"/test.db")
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if _, err := db.Exec(schema.Migrations()[0].SQL); err != nil {
+		t.Fatalf("failed to create initial schema: %v", err)
+	}
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	// Applying migrations again must be harmless
+	for i := 0; i < 2; i++ {
+		if err := storage.InitSchema(ctx); err != nil {
+			t.Fatalf("failed to upgrade schema: %v", err)
+		}
+	}
+
+	article := &model.Article{
+		ID:           "upgrade",
+		Slug:         "upgrade",
+		Title:        "Upgrade",
+		Date:         timeNow(),
+		BodyMarkdown: "# Upgrade",
+		BodyHTML:     "<h1>Upgrade</h1>",
+		WordCount:    1,
+		ContentHash:  "abc",
+	}
+	if err := storage.InsertArticle(ctx, article); err != nil {
+		t.Fatalf("failed to insert article: %v", err)
+	}
+
+	got, err := storage.GetArticleBySlug(ctx, "upgrade")
+	if err != nil {
+		t.Fatalf("failed to get article: %v", err)
+	}
+	if got.BodyHTML != article.BodyHTML || got.ContentHash != "abc" || got.WordCount != 1 {
+		t.Errorf("expected stored content, got %+v", got)
+	}
+}
+
+// TestRenderCache tests storing and retrieving rendered HTML by content hash
+func TestRenderCache(t *testing.T) {
+	db := setupTestDB(t)
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	if _, err := storage.GetRenderCache(ctx, "missing"); err == nil {
+		t.Fatal("expected error for missing cache entry")
+	}
+
+	entry := &model.RenderCache{Hash: "abc", HTML: "<p>first</p>"}
+	if err := storage.SaveRenderCache(ctx, entry); err != nil {
+		t.Fatalf("failed to save render cache: %v", err)
+	}
+
+	entry = &model.RenderCache{Hash: "abc", HTML: "<p>second</p>"}
+	if err := storage.SaveRenderCache(ctx, entry); err != nil {
+		t.Fatalf("failed to replace render cache: %v", err)
+	}
+
+	got, err := storage.GetRenderCache(ctx, "abc")
+	if err != nil {
+		t.Fatalf("failed to get render cache: %v", err)
+	}
+	if got.HTML != "<p>second</p>" {
+		t.Errorf("expected replaced html, got %q", got.HTML)
+	}
+	if got.CreatedAt == nil {
+		t.Error("expected created_at to be set")
+	}
+
+	for _, hash := range []string{"def", "ghi"} {
+		if err := storage.SaveRenderCache(ctx, &model.RenderCache{Hash: hash, HTML: "<p>" + hash + "</p>"}); err != nil {
+			t.Fatalf("failed to save render cache: %v", err)
+		}
+	}
+	removed, err := storage.PruneRenderCache(ctx, []string{"abc", "ghi"})
+	if err != nil {
+		t.Fatalf("failed to prune render cache: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 pruned entry, got %d", removed)
+	}
+	if _, err := storage.GetRenderCache(ctx, "def"); err == nil {
+		t.Error("expected pruned entry to be removed")
+	}
+	if _, err := storage.GetRenderCache(ctx, "ghi"); err != nil {
+		t.Errorf("expected kept entry, got %v", err)
+	}
+
+	if removed, err := storage.PruneRenderCache(ctx, nil); err != nil || removed != 2 {
+		t.Errorf("expected pruning without hashes to remove all entries, got %d, %v", removed, err)
+	}
+}
+
 // BenchmarkInsertArticle benchmarks article insertion
 func BenchmarkInsertArticle(b *testing.B) {
 	db, _ := sqlx.Open("sqlite", ":memory:")
@@ -415,6 +558,7 @@ func BenchmarkInsertArticle(b *testing.B) {
 		ID:          "bench",
 		Slug:        "benchmark",
 		Title:       "Benchmark Article",
+		Date:        timeNow(),
 		Description: "This is for benchmarking",
 		URL:         "/blog/benchmark/",
 	}
@@ -441,6 +585,7 @@ func BenchmarkGetArticleBySlug(b *testing.B) {
 			ID:    "bench-get-" + string(rune(i)),
 			Slug:  "article-" + string(rune(i)),
 			Title: "Article",
+			Date:  timeNow(),
 		}
 		storage.InsertArticle(ctx, article)
 	}
@@ -466,6 +611,7 @@ func BenchmarkGetAllArticles(b *testing.B) {
 			ID:    "bench-all-" + string(rune(i)),
 			Slug:  "article-" + string(rune(i)),
 			Title: "Article",
+			Date:  timeNow(),
 		}
 		storage.InsertArticle(ctx, article)
 	}
@@ -491,6 +637,7 @@ func BenchmarkSearchArticles(b *testing.B) {
 			ID:          "bench-search-" + string(rune(i)),
 			Slug:        "article-" + string(rune(i)),
 			Title:       "Test Article Number " + string(rune(i)),
+			Date:        timeNow(),
 			Description: "This is a test article about testing",
 		}
 		storage.InsertArticle(ctx, article)
@@ -501,3 +648,56 @@ func BenchmarkSearchArticles(b *testing.B) {
 		storage.SearchArticles(ctx, "test")
 	}
 }
+
+// benchmarkMarkdown is an article body with code blocks to highlight
+var benchmarkMarkdown = []byte(strings.Repeat("## Section\n\nSome *text* with `code`.\n\n```go\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```\n\n", 20))
+
+// BenchmarkRenderMarkdown benchmarks rendering an article without the render cache
+func BenchmarkRenderMarkdown(b *testing.B) {
+	renderer := markdown.NewRenderer()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		renderer.Render(benchmarkMarkdown)
+	}
+}
+
+// BenchmarkGetRenderCache benchmarks reading rendered HTML from the render cache
+func BenchmarkGetRenderCache(b *testing.B) {
+	db, _ := sqlx.Open("sqlite", ":memory:")
+	defer db.Close()
+
+	db.Exec(schema.InitialSchema)
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	storage.SaveRenderCache(ctx, &model.RenderCache{
+		Hash: "bench",
+		HTML: string(markdown.NewRenderer().Render(benchmarkMarkdown)),
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		storage.GetRenderCache(ctx, "bench")
+	}
+}
+
+// BenchmarkSaveRenderCache benchmarks storing rendered HTML in the render cache
+func BenchmarkSaveRenderCache(b *testing.B) {
+	db, _ := sqlx.Open("sqlite", ":memory:")
+	defer db.Close()
+
+	db.Exec(schema.InitialSchema)
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	html := string(markdown.NewRenderer().Render(benchmarkMarkdown))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		storage.SaveRenderCache(ctx, &model.RenderCache{
+			Hash: "bench-" + strconv.Itoa(i%100),
+			HTML: html,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// SetArticleTags replaces the tags assigned to an article.
+// Tags without any articles are removed.
+func SetArticleTags(ctx context.Context, db *sqlx.DB, articleID string, names []string) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var articleTag *model.ArticleTag
+	if _, err := tx.ExecContext(ctx, articleTag.Delete(model.WithWhere("article_id=?")), articleID); err != nil {
+		return err
+	}
+
+	// Drop assignments left behind by replaced article rows
+	if _, err := tx.ExecContext(ctx, articleTag.Delete(model.WithWhere("article_id NOT IN (SELECT id FROM article)"))); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		tag := &model.Tag{
+			Slug: model.Slugify(name),
+			Name: name,
+		}
+		if tag.Slug == "" {
+			continue
+		}
+
+		if _, err := tx.NamedExecContext(ctx, tag.Insert(model.WithStatement("INSERT OR IGNORE INTO")), tag); err != nil {
+			return err
+		}
+
+		assignment := &model.ArticleTag{
+			ArticleID: articleID,
+			Tag:       tag.Slug,
+		}
+		if _, err := tx.NamedExecContext(ctx, assignment.Insert(model.WithStatement("INSERT OR IGNORE INTO")), assignment); err != nil {
+			return err
+		}
+	}
+
+	var tag *model.Tag
+	if _, err := tx.ExecContext(ctx, tag.Delete(model.WithWhere("slug NOT IN (SELECT tag FROM article_tag)"))); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTag retrieves a single tag by slug
+func GetTag(ctx context.Context, db *sqlx.DB, slug string) (*model.Tag, error) {
+	var tag model.Tag
+	query := tag.Select(model.WithWhere("slug=?"))
+
+	if err := db.GetContext(ctx, &tag, query, slug); err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// GetTags retrieves all tags with their article counts, most used first
+func GetTags(ctx context.Context, db *sqlx.DB, visibility Visibility) ([]model.TagCount, error) {
+	where, args := visibility.Where("article.", "1=1")
+	query := `SELECT tag.slug, tag.name, COUNT(*) AS count
+	FROM tag
+	JOIN article_tag ON article_tag.tag = tag.slug
+	JOIN article ON article.id = article_tag.article_id
+	WHERE ` + where + `
+	GROUP BY tag.slug, tag.name
+	ORDER BY count DESC, tag.name`
+
+	tags := []model.TagCount{}
+	if err := db.SelectContext(ctx, &tags, query, args...); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetArticleTags retrieves the tags assigned to an article
+func GetArticleTags(ctx context.Context, db *sqlx.DB, articleID string) ([]model.Tag, error) {
+	var tag *model.Tag
+	query := tag.Select(
+		model.WithWhere("slug IN (SELECT tag FROM article_tag WHERE article_id=?)"),
+		model.WithOrderBy("name"),
+	)
+
+	tags := []model.Tag{}
+	if err := db.SelectContext(ctx, &tags, query, articleID); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetArticlesByTag retrieves articles with the given tag ordered by date descending
+func GetArticlesByTag(ctx context.Context, db *sqlx.DB, visibility Visibility, slug string, start, length int) ([]model.Article, error) {
+	var article *model.Article
+	where, args := visibility.Where("", "id IN (SELECT article_id FROM article_tag WHERE tag=?)")
+	query := article.Select(
+		model.WithWhere(where),
+		model.WithOrderBy("date DESC"),
+		model.WithLimit(start, length),
+	)
+
+	articles := []model.Article{}
+	if err := db.SelectContext(ctx, &articles, query, append([]any{slug}, args...)...); err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
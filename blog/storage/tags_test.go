@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// TestStorageIntegration_Tags tests tag assignment, counts and lookups
+func TestStorageIntegration_Tags(t *testing.T) {
+	db := setupTestDB(t)
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	articles := []model.Article{
+		{ID: "tag-1", Slug: "grid-gap", Title: "Grid Gap", Date: timePtr(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))},
+		{ID: "tag-2", Slug: "css-marquee", Title: "CSS Marquee", Date: timePtr(time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC))},
+		{ID: "tag-3", Slug: "go-tips", Title: "Go Tips", Date: timePtr(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))},
+	}
+	for i := range articles {
+		require.NoError(t, storage.InsertArticle(ctx, &articles[i]))
+	}
+
+	require.NoError(t, storage.SetArticleTags(ctx, "tag-1", []string{"CSS", "Layout"}))
+	require.NoError(t, storage.SetArticleTags(ctx, "tag-2", []string{"CSS", "Animation"}))
+	require.NoError(t, storage.SetArticleTags(ctx, "tag-3", []string{"Go"}))
+
+	t.Run("tag counts", func(t *testing.T) {
+		tags, err := storage.GetTags(ctx)
+		require.NoError(t, err)
+		require.Len(t, tags, 4)
+		require.Equal(t, "css", tags[0].Slug)
+		require.Equal(t, "CSS", tags[0].Name)
+		require.Equal(t, 2, tags[0].Count)
+	})
+
+	t.Run("articles by tag", func(t *testing.T) {
+		byTag, err := storage.GetArticlesByTag(ctx, "css", 0, 10)
+		require.NoError(t, err)
+		require.Len(t, byTag, 2)
+		require.Equal(t, "css-marquee", byTag[0].Slug)
+		require.Equal(t, "grid-gap", byTag[1].Slug)
+	})
+
+	t.Run("article tags", func(t *testing.T) {
+		tags, err := storage.GetArticleTags(ctx, "tag-1")
+		require.NoError(t, err)
+		require.Equal(t, []model.Tag{{Slug: "css", Name: "CSS"}, {Slug: "layout", Name: "Layout"}}, tags)
+	})
+
+	t.Run("retag removes unused tags", func(t *testing.T) {
+		require.NoError(t, storage.SetArticleTags(ctx, "tag-3", []string{"golang"}))
+
+		_, err := storage.GetTag(ctx, "go")
+		require.Error(t, err)
+
+		tag, err := storage.GetTag(ctx, "golang")
+		require.NoError(t, err)
+		require.Equal(t, "golang", tag.Name)
+	})
+
+	t.Run("deleted article drops tags", func(t *testing.T) {
+		require.NoError(t, storage.DeleteArticle(ctx, "css-marquee"))
+
+		byTag, err := storage.GetArticlesByTag(ctx, "css", 0, 10)
+		require.NoError(t, err)
+		require.Len(t, byTag, 1)
+
+		tags, err := storage.GetTags(ctx)
+		require.NoError(t, err)
+		for _, tag := range tags {
+			require.NotEqual(t, "animation", tag.Slug)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// Visibility controls which articles are returned by queries.
+//
+// Drafts and scheduled articles, dated in the future, are only visible in
+// preview mode. Unlisted articles can be retrieved by slug, but are left
+// out of lists, feeds and search results.
+type Visibility struct {
+	// Preview includes drafts and scheduled articles
+	Preview bool
+
+	// Listed excludes unlisted articles
+	Listed bool
+
+	// Now is the publishing cutoff for scheduled articles
+	Now time.Time
+}
+
+// Where combines clause with the visibility conditions for the article
+// table referenced by prefix (e.g. "article."), returning the clause and
+// the arguments for the visibility conditions.
+func (v Visibility) Where(prefix, clause string) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if clause != "" {
+		conditions = append(conditions, clause)
+	}
+	if v.Listed {
+		conditions = append(conditions, prefix+"unlisted = 0")
+	}
+	if !v.Preview {
+		conditions = append(conditions, prefix+"draft = 0", prefix+"date <= ?")
+		args = append(args, v.Now.UTC())
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// TestStorageIntegration_Visibility tests draft, scheduled and unlisted articles
+func TestStorageIntegration_Visibility(t *testing.T) {
+	db := setupTestDB(t)
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	past := time.Now().Add(-24 * time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	articles := []model.Article{
+		{ID: "vis-1", Slug: "published", Title: "Published", Date: timePtr(past)},
+		{ID: "vis-2", Slug: "draft", Title: "Draft", Date: timePtr(past), Draft: true},
+		{ID: "vis-3", Slug: "scheduled", Title: "Scheduled", Date: timePtr(future)},
+		{ID: "vis-4", Slug: "unlisted", Title: "Unlisted", Date: timePtr(past), Unlisted: true},
+	}
+	for i := range articles {
+		require.NoError(t, storage.InsertArticle(ctx, &articles[i]))
+		require.NoError(t, storage.SetArticleTags(ctx, articles[i].ID, []string{"Visibility"}))
+	}
+
+	slugs := func(articles []model.Article) []string {
+		result := make([]string, 0, len(articles))
+		for _, article := range articles {
+			result = append(result, article.Slug)
+		}
+		return result
+	}
+
+	t.Run("lists only published articles", func(t *testing.T) {
+		list, err := storage.GetArticles(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"published"}, slugs(list))
+
+		count, err := storage.CountArticles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		byTag, err := storage.GetArticlesByTag(ctx, "visibility", 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"published"}, slugs(byTag))
+
+		tags, err := storage.GetTags(ctx)
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		require.Equal(t, 1, tags[0].Count)
+	})
+
+	t.Run("search skips hidden articles", func(t *testing.T) {
+		for _, slug := range []string{"draft", "scheduled", "unlisted"} {
+			require.NoError(t, storage.IndexArticleBody(ctx, slug, "hidden body"))
+		}
+
+		results, err := storage.SearchArticles(ctx, "hidden")
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("unlisted articles are reachable by slug", func(t *testing.T) {
+		article, err := storage.GetArticleBySlug(ctx, "unlisted")
+		require.NoError(t, err)
+		require.True(t, article.Unlisted)
+
+		list, err := storage.WithUnlisted().GetArticles(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"published", "unlisted"}, slugs(list))
+	})
+
+	t.Run("drafts and scheduled articles are hidden by slug", func(t *testing.T) {
+		_, err := storage.GetArticleBySlug(ctx, "draft")
+		require.Error(t, err)
+
+		_, err = storage.GetArticleBySlug(ctx, "scheduled")
+		require.Error(t, err)
+	})
+
+	t.Run("preview shows drafts and scheduled articles", func(t *testing.T) {
+		preview := NewStorage(db)
+		preview.SetPreview(true)
+
+		list, err := preview.GetArticles(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"scheduled", "draft", "published"}, slugs(list))
+
+		article, err := preview.GetArticleBySlug(ctx, "draft")
+		require.NoError(t, err)
+		require.True(t, article.Draft)
+	})
+
+	t.Run("scheduled articles publish once their date passes", func(t *testing.T) {
+		visibility := Visibility{Now: future.Add(time.Minute)}
+
+		article, err := GetArticleBySlug(ctx, db, visibility, "scheduled")
+		require.NoError(t, err)
+		require.Equal(t, "Scheduled", article.Title)
+
+		visibility.Listed = true
+		list, err := GetArticles(ctx, db, visibility, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"scheduled", "published"}, slugs(list))
+	})
+}
@@ -0,0 +1,39 @@
+package blog
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/titpetric/platform-example/blog/view"
+)
+
+// GetStylesheet returns a handler serving a generated stylesheet
+func (h *Handlers) GetStylesheet(sheet view.Stylesheet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.writeRendered(w, r, "text/css; charset=utf-8", func(w io.Writer) error {
+			css, err := sheet.CSS()
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(css)
+			return err
+		})
+	}
+}
+
+// generateStylesheets writes the stylesheets of highlighted code
+func (g *Generator) generateStylesheets(h *Handlers) error {
+	for _, sheet := range h.views.SyntaxStylesheets() {
+		css, err := sheet.CSS()
+		if err != nil {
+			return err
+		}
+		if err := g.write(sheet.Path, inputHash(string(css)), func(w io.Writer) error {
+			_, err := w.Write(css)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
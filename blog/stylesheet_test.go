@@ -0,0 +1,27 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/view"
+)
+
+func TestGetStylesheet(t *testing.T) {
+	h := &Handlers{}
+
+	w := httptest.NewRecorder()
+	sheet := view.Stylesheet{Path: "assets/css/chroma-github-dark.css", Style: "github", Appearance: "dark"}
+	h.GetStylesheet(sheet)(w, httptest.NewRequest(http.MethodGet, sheet.Href(), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), "[data-appearance=dark] .chroma .k {")
+
+	w = httptest.NewRecorder()
+	sheet.Style = "no-such-style"
+	h.GetStylesheet(sheet)(w, httptest.NewRequest(http.MethodGet, sheet.Href(), nil))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
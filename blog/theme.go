@@ -1,6 +1,109 @@
 package blog
 
-import "embed"
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"log"
+	"sync"
+)
 
 //go:embed all:theme
 var themeFS embed.FS
+
+// themeDir is the live theme directory, its files override the embedded theme
+const themeDir = "theme"
+
+// themeVersion identifies the contents of the theme, so responses rendered
+// with a changed theme get different entity tags and cache keys. The
+// embedded theme doesn't change, so only the live theme directory of an
+// overlay is hashed again, when the watcher refreshes the version.
+type themeVersion struct {
+	fs fs.FS
+
+	// base and baseComponents are the hashes of the embedded theme and of
+	// its components
+	base           string
+	baseComponents string
+
+	mu         sync.Mutex
+	value      string
+	components string
+}
+
+// newThemeVersion creates the version of the theme fsys and hashes it
+func newThemeVersion(fsys fs.FS) *themeVersion {
+	t := &themeVersion{fs: fsys}
+
+	base := fsys
+	if overlay, ok := fsys.(*OverlayFS); ok {
+		base = overlay.Lower
+	}
+
+	var err error
+	if t.base, err = hashFS(base); err != nil {
+		log.Printf("[blog] can't hash theme: %v", err)
+	}
+	if t.baseComponents, err = hashComponents(base); err != nil {
+		log.Printf("[blog] can't hash theme: %v", err)
+	}
+	t.value, t.components = t.base, t.baseComponents
+	t.Refresh()
+	return t
+}
+
+// Get returns the hash of the theme
+func (t *themeVersion) Get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.value
+}
+
+// Components returns the hash of the components of the theme, which
+// shortcodes render into the stored HTML of articles
+func (t *themeVersion) Components() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.components
+}
+
+// Refresh hashes the live theme directory again, returning true if the
+// version changed
+func (t *themeVersion) Refresh() bool {
+	overlay, ok := t.fs.(*OverlayFS)
+	if !ok {
+		return false
+	}
+
+	live, err := hashFS(overlay.Upper)
+	if err != nil {
+		log.Printf("[blog] can't hash theme: %v", err)
+		return false
+	}
+	liveComponents, err := hashComponents(overlay.Upper)
+	if err != nil {
+		log.Printf("[blog] can't hash theme: %v", err)
+		return false
+	}
+	value := inputHash(t.base, live)
+	components := inputHash(t.baseComponents, liveComponents)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	changed := value != t.value
+	t.value, t.components = value, components
+	return changed
+}
+
+// hashComponents hashes the components directory of fsys, empty if there's none
+func hashComponents(fsys fs.FS) (string, error) {
+	if _, err := fs.Stat(fsys, "components"); errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	components, err := fs.Sub(fsys, "components")
+	if err != nil {
+		return "", err
+	}
+	return hashFS(components)
+}
@@ -0,0 +1,44 @@
+package view
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ErrorData holds the data required for rendering an error page
+type ErrorData struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      int    `json:"status"`
+}
+
+// Map converts ErrorData to a map[string]any
+func (d *ErrorData) Map() map[string]any {
+	return map[string]any{
+		"title":       d.Title,
+		"description": d.Description,
+		"status":      d.Status,
+	}
+}
+
+// Error renders the error page for the status, `pages/404.vuego` for
+// 404 Not Found and `pages/500.vuego` for other errors
+func (v *Views) Error(ctx context.Context, w io.Writer, data *ErrorData) error {
+	templateData := data.Map()
+
+	if data.Status == http.StatusNotFound {
+		return v.Render(ctx, w, "pages/404.vuego", templateData)
+	}
+	return v.Render(ctx, w, "pages/500.vuego", templateData)
+}
+
+// ErrorFromStatus creates ErrorData for a status code and a description
+// that is safe to show to visitors
+func (v *Views) ErrorFromStatus(status int, description string) *ErrorData {
+	return &ErrorData{
+		Title:       http.StatusText(status),
+		Description: description,
+		Status:      status,
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"html"
 	"io"
-	"os"
 	"time"
 
 	"github.com/titpetric/platform-example/blog/model"
@@ -13,6 +12,19 @@ import (
 
 // AtomFeed generates an Atom XML feed for articles
 func (v *Views) AtomFeed(ctx context.Context, w io.Writer, articles []model.Article) error {
+	return v.atomFeed(w, "", "/feed.xml", "", articles)
+}
+
+// TagFeed generates an Atom XML feed for articles with the given tag
+func (v *Views) TagFeed(ctx context.Context, w io.Writer, tag *model.Tag, articles []model.Article) error {
+	tagPath := TagURL(tag.Slug)
+	return v.atomFeed(w, " - "+tag.Name, tagPath+"feed.xml", tagPath, articles)
+}
+
+// atomFeed writes an Atom feed. The title suffix is appended to the site
+// title, selfPath is the feed path and idPath is appended to the site url
+// to form the feed id and alternate link.
+func (v *Views) atomFeed(w io.Writer, titleSuffix, selfPath, idPath string, articles []model.Article) error {
 	var meta map[string]any
 	var author map[string]any
 
@@ -47,35 +59,27 @@ func (v *Views) AtomFeed(ctx context.Context, w io.Writer, articles []model.Arti
 <feed xmlns="http://www.w3.org/2005/Atom" xml:base="%s">
   <title>%s</title>
   <subtitle>Blogging general thoughts and rambles, code snippets, and front-end web dev discoveries</subtitle>
-  <link href="%s/feed.xml" rel="self"/>
-  <link href="%s"/>
+  <link href="%s%s" rel="self"/>
+  <link href="%s%s"/>
   <updated>%s</updated>
-  <id>%s</id>
+  <id>%s%s</id>
   <author>
     <name>%s</name>
     <email>%s</email>
   </author>
-`, meta["url"], escapeXML(meta["title"]), meta["url"], meta["url"], newestDate.Format(time.RFC3339), meta["url"], escapeXML(author["name"]), author["email"]))
+`, meta["url"], escapeXML(fmt.Sprint(meta["title"])+titleSuffix), meta["url"], selfPath, meta["url"], idPath, newestDate.Format(time.RFC3339), meta["url"], idPath, escapeXML(author["name"]), author["email"]))
 
-	// Add entries for each article
+	// Add entries for each article. The entry id stays the same when the
+	// permalink changes, so feed readers don't show the article again.
 	for _, article := range articles {
-
-		content, err := os.ReadFile(article.Filename)
-		if err != nil {
-			return err
-		}
-
-		// Strip front matter from content
-		content = StripFrontMatter(content)
-
 		entryXML := fmt.Sprintf(`  <entry>
     <title>%s</title>
-    <link href="%s/blog/%s"/>
+    <link href="%s%s"/>
     <updated>%s</updated>
     <id>%s/blog/%s</id>
     <content xml:lang="%s" type="html">%s</content>
   </entry>
-`, escapeXML(article.Title), meta["url"], article.Slug, article.Date.Format(time.RFC3339), meta["url"], article.Slug, language, escapeXML(content))
+`, escapeXML(article.Title), meta["url"], escapeXML(article.URL), article.Date.Format(time.RFC3339), meta["url"], article.Slug, language, escapeXML(article.BodyHTML))
 
 		io.WriteString(w, entryXML)
 	}
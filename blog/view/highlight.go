@@ -0,0 +1,66 @@
+package view
+
+import (
+	"github.com/titpetric/platform-example/blog/markdown"
+)
+
+// Stylesheet is a stylesheet generated for the site
+type Stylesheet struct {
+	// Path is the path of the stylesheet, e.g. `assets/css/chroma-monokai-dark.css`
+	Path string
+
+	// Style is the name of the Chroma style
+	Style string
+
+	// Appearance is the appearance the stylesheet applies to
+	Appearance string
+}
+
+// Href returns the URL path of the stylesheet
+func (s Stylesheet) Href() string {
+	return "/" + s.Path
+}
+
+// CSS renders the stylesheet
+func (s Stylesheet) CSS() ([]byte, error) {
+	return markdown.Stylesheet(s.Style, s.Appearance)
+}
+
+// syntaxStylesheets returns the stylesheets of highlighted code, with the
+// Chroma style of each appearance from `highlight` in meta.yml:
+//
+//	highlight:
+//	  light: github
+//	  dark: monokai
+//
+// Appearances without a style use markdown.DefaultLightStyle for the light
+// appearance, and markdown.DefaultStyle for the dark one.
+func syntaxStylesheets(data map[string]any) []Stylesheet {
+	var highlight map[string]any
+	if meta, ok := data["meta"].(map[string]any); ok {
+		highlight, _ = meta["highlight"].(map[string]any)
+	}
+
+	result := make([]Stylesheet, 0, 2)
+	for _, appearance := range []string{markdown.AppearanceLight, markdown.AppearanceDark} {
+		style, _ := highlight[appearance].(string)
+		if style == "" {
+			style = markdown.DefaultStyle
+			if appearance == markdown.AppearanceLight {
+				style = markdown.DefaultLightStyle
+			}
+		}
+		result = append(result, Stylesheet{
+			Path:       "assets/css/chroma-" + style + "-" + appearance + ".css",
+			Style:      style,
+			Appearance: appearance,
+		})
+	}
+	return result
+}
+
+// SyntaxStylesheets returns the stylesheets of highlighted code, one for
+// each appearance
+func (v *Views) SyntaxStylesheets() []Stylesheet {
+	return v.syntaxStylesheets
+}
@@ -21,6 +21,9 @@ type IndexData struct {
 	OGImage     string          `json:"ogImage"`
 	Articles    []model.Article `json:"articles"`
 	Total       int             `json:"total"`
+	Page        int             `json:"page"`
+	Pages       int             `json:"pages"`
+	Links       model.PageLinks `json:"links"`
 }
 
 // Map converts IndexData to a map[string]any
@@ -31,18 +34,15 @@ func (d *IndexData) Map() map[string]any {
 		"ogImage":     d.OGImage,
 		"articles":    d.Articles,
 		"total":       d.Total,
+		"page":        d.Page,
+		"pages":       d.Pages,
+		"links":       d.Links,
 	}
 }
 
 // Index renders the blog index/list page
 func (v *Views) Index(ctx context.Context, w io.Writer, data *IndexData) error {
-	// Build the context data
 	templateData := data.Map()
-	for k, v := range v.data {
-		if _, ok := templateData[k]; !ok {
-			templateData[k] = v
-		}
-	}
 
 	// Render the index page
 	return v.Render(ctx, w, "pages/index.vuego", templateData)
@@ -95,13 +95,7 @@ func loadFile(w *map[string]any, key string, filename string) error {
 
 // Blog renders the blog list page
 func (v *Views) Blog(ctx context.Context, w io.Writer, data *IndexData) error {
-	// Build the context data
 	templateData := data.Map()
-	for k, v := range v.data {
-		if _, ok := templateData[k]; !ok {
-			templateData[k] = v
-		}
-	}
 
 	var buf bytes.Buffer
 	if err := v.Render(ctx, &buf, "pages/blog.vuego", templateData); err != nil {
@@ -118,5 +112,20 @@ func (v *Views) IndexFromArticles(articles []model.Article) *IndexData {
 		Description: "Read my latest articles and posts",
 		Articles:    articles,
 		Total:       len(articles),
+		Page:        1,
+		Pages:       1,
+	}
+}
+
+// IndexFromList creates IndexData for a page of a paginated article list
+func (v *Views) IndexFromList(list *model.ArticleList) *IndexData {
+	data := v.IndexFromArticles(list.Articles)
+	data.Total = list.Total
+	data.Page = list.Page
+	data.Pages = list.Pages
+	data.Links = list.Links
+	if list.Page > 1 {
+		data.Title = fmt.Sprintf("%s, page %d", data.Title, list.Page)
 	}
+	return data
 }
@@ -0,0 +1,25 @@
+package view
+
+import (
+	"context"
+	"io"
+)
+
+// PageData holds the data required for rendering a theme page
+type PageData struct {
+	URL string `json:"url"`
+}
+
+// Map converts PageData to a map[string]any
+func (d *PageData) Map() map[string]any {
+	return map[string]any{
+		"url": d.URL,
+	}
+}
+
+// Page renders a theme page template, e.g. `pages/about.vuego`
+func (v *Views) Page(ctx context.Context, w io.Writer, template string, data *PageData) error {
+	templateData := data.Map()
+
+	return v.Render(ctx, w, template, templateData)
+}
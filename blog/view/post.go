@@ -2,9 +2,12 @@ package view
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"log"
 	"time"
 
+	"github.com/titpetric/platform-example/blog/markdown"
 	"github.com/titpetric/platform-example/blog/model"
 )
 
@@ -17,6 +20,10 @@ type PostData struct {
 	Content     string
 	Date        *time.Time
 	Classnames  string
+
+	// TOC holds the headings of the content for the table of contents.
+	// It's empty if the article has no headings or turns it off.
+	TOC []*markdown.Heading
 }
 
 // Map converts PostData to a map[string]any
@@ -29,18 +36,13 @@ func (d *PostData) Map() map[string]any {
 		"content":     d.Content,
 		"date":        d.Date,
 		"classnames":  d.Classnames,
+		"toc":         d.TOC,
 	}
 }
 
 // Post renders the post layout template
 func (v *Views) Post(ctx context.Context, w io.Writer, data *PostData) error {
-	// Build the context data
 	templateData := data.Map()
-	for k, v := range v.data {
-		if _, ok := templateData[k]; !ok {
-			templateData[k] = v
-		}
-	}
 
 	// Render the post layout
 	return v.Render(ctx, w, "layouts/post.vuego", templateData)
@@ -48,7 +50,7 @@ func (v *Views) Post(ctx context.Context, w io.Writer, data *PostData) error {
 
 // PostFromArticle creates PostData from an Article
 func (v *Views) PostFromArticle(article *model.Article, content string) *PostData {
-	return &PostData{
+	data := &PostData{
 		Slug:        article.Slug,
 		Title:       article.Title,
 		Description: article.Description,
@@ -57,4 +59,13 @@ func (v *Views) PostFromArticle(article *model.Article, content string) *PostDat
 		Date:        article.Date,
 		Classnames:  "prose",
 	}
+
+	if article.TOC != "" {
+		// The article renders without a table of contents if it can't be read
+		if err := json.Unmarshal([]byte(article.TOC), &data.TOC); err != nil {
+			log.Printf("[view] can't decode table of contents of %s: %v", article.Slug, err)
+		}
+	}
+
+	return data
 }
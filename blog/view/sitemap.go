@@ -0,0 +1,41 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SitemapURL is a page listed in the sitemap
+type SitemapURL struct {
+	// Path is the URL path of the page
+	Path string
+
+	// Modified is the time the page last changed, if known
+	Modified *time.Time
+}
+
+// Sitemap generates a sitemap.xml listing the given pages
+func (v *Views) Sitemap(ctx context.Context, w io.Writer, urls []SitemapURL) error {
+	var siteURL any
+	if meta, ok := v.data["meta"].(map[string]any); ok {
+		siteURL = meta["url"]
+	}
+
+	io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+`)
+
+	for _, u := range urls {
+		io.WriteString(w, fmt.Sprintf("  <url>\n    <loc>%s%s</loc>\n", escapeXML(siteURL), escapeXML(u.Path)))
+		if u.Modified != nil {
+			io.WriteString(w, fmt.Sprintf("    <lastmod>%s</lastmod>\n", u.Modified.UTC().Format(time.RFC3339)))
+		}
+		io.WriteString(w, "  </url>\n")
+	}
+
+	io.WriteString(w, `</urlset>`)
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package view
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// TagURL returns the URL path of the article list for a tag slug
+func TagURL(slug string) string {
+	return "/blog/tags/" + slug + "/"
+}
+
+// TagData holds the data required for rendering the article list of a tag
+type TagData struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Tag         *model.Tag      `json:"tag"`
+	URL         string          `json:"url"`
+	Feed        string          `json:"feed"`
+	Articles    []model.Article `json:"articles"`
+	Total       int             `json:"total"`
+}
+
+// MarshalJSON encodes the tag with article summaries, so the response
+// doesn't carry the content of every article
+func (d TagData) MarshalJSON() ([]byte, error) {
+	type tag TagData
+	return json.Marshal(struct {
+		tag
+		Articles []model.ArticleSummary `json:"articles"`
+	}{tag(d), model.Summaries(d.Articles)})
+}
+
+// Map converts TagData to a map[string]any
+func (d *TagData) Map() map[string]any {
+	return map[string]any{
+		"title":       d.Title,
+		"description": d.Description,
+		"tag":         d.Tag,
+		"url":         d.URL,
+		"feed":        d.Feed,
+		"articles":    d.Articles,
+		"total":       d.Total,
+	}
+}
+
+// TagsData holds the data required for rendering the tag index
+type TagsData struct {
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Tags        []model.TagCount `json:"tags"`
+	Total       int              `json:"total"`
+}
+
+// Map converts TagsData to a map[string]any
+func (d *TagsData) Map() map[string]any {
+	return map[string]any{
+		"title":       d.Title,
+		"description": d.Description,
+		"tags":        d.Tags,
+		"total":       d.Total,
+	}
+}
+
+// Tag renders the article list for a single tag
+func (v *Views) Tag(ctx context.Context, w io.Writer, data *TagData) error {
+	templateData := data.Map()
+
+	return v.Render(ctx, w, "tags/tag.vuego", templateData)
+}
+
+// Tags renders the index of all tags
+func (v *Views) Tags(ctx context.Context, w io.Writer, data *TagsData) error {
+	templateData := data.Map()
+
+	return v.Render(ctx, w, "tags/index.vuego", templateData)
+}
+
+// TagFromArticles creates TagData for a tag and its articles
+func (v *Views) TagFromArticles(tag *model.Tag, articles []model.Article) *TagData {
+	url := TagURL(tag.Slug)
+	return &TagData{
+		Title:       "Posts tagged " + tag.Name,
+		Description: "Articles and posts tagged " + tag.Name,
+		Tag:         tag,
+		URL:         url,
+		Feed:        url + "feed.xml",
+		Articles:    articles,
+		Total:       len(articles),
+	}
+}
+
+// TagsFromCounts creates TagsData from tag counts, leaving tags unmodified
+func (v *Views) TagsFromCounts(tags []model.TagCount) *TagsData {
+	tags = slices.Clone(tags)
+	for i := range tags {
+		tags[i].URL = TagURL(tags[i].Slug)
+	}
+	return &TagsData{
+		Title:       "Tags",
+		Description: "Browse articles and posts by tag",
+		Tags:        tags,
+		Total:       len(tags),
+	}
+}
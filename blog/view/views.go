@@ -9,6 +9,8 @@ import (
 type Views struct {
 	*layout.Renderer
 	data map[string]any
+
+	syntaxStylesheets []Stylesheet
 }
 
 func NewViews(root fs.FS) (*Views, error) {
@@ -17,8 +19,13 @@ func NewViews(root fs.FS) (*Views, error) {
 		return nil, err
 	}
 
+	// Layouts link the stylesheets of highlighted code
+	stylesheets := syntaxStylesheets(data)
+	data["syntaxStylesheets"] = stylesheets
+
 	return &Views{
-		Renderer: layout.NewRenderer(root, data),
-		data:     data,
+		Renderer:          layout.NewRenderer(root, data),
+		data:              data,
+		syntaxStylesheets: stylesheets,
 	}, nil
 }
@@ -0,0 +1,142 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/model"
+)
+
+// newTestViews loads the views of the theme with the site data of appdata
+func newTestViews(t *testing.T) *Views {
+	t.Helper()
+
+	theme, err := filepath.Abs("../theme")
+	require.NoError(t, err)
+
+	t.Chdir("../appdata")
+
+	views, err := NewViews(os.DirFS(theme))
+	require.NoError(t, err)
+	return views
+}
+
+func TestViewsConcurrent(t *testing.T) {
+	views := newTestViews(t)
+	ctx := context.Background()
+	date := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	render := func(i int) (string, error) {
+		var buf bytes.Buffer
+		if i%2 == 0 {
+			err := views.Post(ctx, &buf, &PostData{
+				Slug:       fmt.Sprintf("post-%d", i),
+				Title:      fmt.Sprintf("Post %d", i),
+				Content:    fmt.Sprintf("<p>Content of post %d</p>", i),
+				Date:       &date,
+				Classnames: "prose",
+			})
+			return buf.String(), err
+		}
+
+		err := views.Index(ctx, &buf, &IndexData{
+			Title: fmt.Sprintf("Index %d", i),
+			Articles: []model.Article{
+				{Title: fmt.Sprintf("Article %d", i), URL: fmt.Sprintf("/blog/article-%d/", i), Date: &date},
+			},
+		})
+		return buf.String(), err
+	}
+
+	const renders = 32
+	outputs := make([]string, renders)
+	errs := make([]error, renders)
+	var wg sync.WaitGroup
+	for i := range renders {
+		wg.Go(func() {
+			outputs[i], errs[i] = render(i)
+		})
+	}
+	wg.Wait()
+
+	for i, output := range outputs {
+		require.NoError(t, errs[i])
+		require.Equal(t, 1, strings.Count(output, "<html"), "render %d", i)
+
+		own := fmt.Sprintf("Content of post %d<", i)
+		if i%2 != 0 {
+			own = fmt.Sprintf("Article %d<", i)
+		}
+		require.Contains(t, output, own, "render %d", i)
+
+		for j := range renders {
+			if j == i {
+				continue
+			}
+			require.NotContains(t, output, fmt.Sprintf("Content of post %d<", j), "render %d leaks post %d", i, j)
+			require.NotContains(t, output, fmt.Sprintf("Article %d<", j), "render %d leaks article %d", i, j)
+		}
+	}
+
+	_, ok := views.data["content"]
+	require.False(t, ok, "site data must not be modified")
+}
+
+func TestPostTableOfContents(t *testing.T) {
+	views := newTestViews(t)
+	ctx := context.Background()
+	date := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	article := &model.Article{
+		Slug:  "toc",
+		Title: "Contents",
+		Date:  &date,
+		TOC:   `[{"id":"setup","title":"Setup","level":2,"children":[{"id":"install","title":"Install","level":3}]}]`,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, views.Post(ctx, &buf, views.PostFromArticle(article, `<h2 id="setup">Setup</h2>`)))
+	require.Contains(t, buf.String(), `class="table-of-contents"`)
+	require.Contains(t, buf.String(), `<a href="#setup">Setup</a>`)
+	require.Contains(t, buf.String(), `<a href="#install">Install</a>`)
+
+	// Articles without headings, or with toc: false, have no table of contents
+	article.TOC = ""
+	buf.Reset()
+	require.NoError(t, views.Post(ctx, &buf, views.PostFromArticle(article, "<p>Text</p>")))
+	require.NotContains(t, buf.String(), `class="table-of-contents"`)
+}
+
+func TestSyntaxStylesheets(t *testing.T) {
+	views := newTestViews(t)
+
+	// The styles of appdata/config/meta.yml
+	require.Equal(t, []Stylesheet{
+		{Path: "assets/css/chroma-github-light.css", Style: "github", Appearance: "light"},
+		{Path: "assets/css/chroma-monokai-dark.css", Style: "monokai", Appearance: "dark"},
+	}, views.SyntaxStylesheets())
+
+	var buf bytes.Buffer
+	require.NoError(t, views.Post(context.Background(), &buf, &PostData{Title: "Styles"}))
+	require.Contains(t, buf.String(), `<link rel="stylesheet" href="/assets/css/chroma-github-light.css">`)
+	require.Contains(t, buf.String(), `<link rel="stylesheet" href="/assets/css/chroma-monokai-dark.css">`)
+
+	t.Run("defaults", func(t *testing.T) {
+		stylesheets := syntaxStylesheets(map[string]any{
+			"meta": map[string]any{"highlight": map[string]any{"dark": "dracula"}},
+		})
+		require.Equal(t, []Stylesheet{
+			{Path: "assets/css/chroma-github-light.css", Style: "github", Appearance: "light"},
+			{Path: "assets/css/chroma-dracula-dark.css", Style: "dracula", Appearance: "dark"},
+		}, stylesheets)
+	})
+}
@@ -0,0 +1,298 @@
+package blog
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// watchDebounce is the quiet period after the last filesystem event
+	// before a reindex runs, so an editor's save burst reindexes once.
+	watchDebounce = 250 * time.Millisecond
+
+	// watchPollInterval is how often the data directory is polled when
+	// filesystem notifications are not available.
+	watchPollInterval = 2 * time.Second
+)
+
+// fileState records what a markdown file looked like when it was last indexed
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watch monitors the data directory and reindexes changed markdown files,
+// and the live theme directory, rendering articles again when the components
+// change. It uses filesystem notifications and falls back to polling if those
+// are unavailable.
+func (m *Module) watch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[blog] file notifications unavailable, polling %s: %v", m.dataDir, err)
+		m.poll(ctx)
+		return
+	}
+	defer watcher.Close()
+
+	if err := m.addWatches(watcher); err != nil {
+		log.Printf("[blog] can't watch %s, polling instead: %v", m.dataDir, err)
+		m.poll(ctx)
+		return
+	}
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	// themeChanged is set until the theme version is refreshed
+	themeChanged := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if inDirs(filepath.Clean(event.Name), []string{themeDir}) {
+				themeChanged = true
+				debounce.Reset(watchDebounce)
+			} else if m.watchesEvent(event) {
+				debounce.Reset(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[blog] watch error: %v", err)
+		case <-debounce.C:
+			if themeChanged {
+				m.theme.Refresh()
+				themeChanged = false
+			}
+			m.reindexAndLog(ctx)
+
+			// Pick up newly created subdirectories
+			if err := m.addWatches(watcher); err != nil {
+				log.Printf("[blog] watch error: %v", err)
+			}
+		}
+	}
+}
+
+// poll reindexes the data directory and refreshes the theme version on a
+// fixed interval until ctx is done
+func (m *Module) poll(ctx context.Context) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.theme.Refresh()
+			m.reindexAndLog(ctx)
+		}
+	}
+}
+
+// addWatches registers the data directory and all of its subdirectories with
+// watcher, the live theme directory and the directory of the redirects file
+// if they exist
+func (m *Module) addWatches(watcher *fsnotify.Watcher) error {
+	if err := addWatchTree(watcher, m.dataDir); err != nil {
+		return err
+	}
+
+	if _, ok := m.themeFS.(*OverlayFS); ok {
+		if err := addWatchTree(watcher, themeDir); err != nil {
+			return err
+		}
+	}
+
+	// Editors replace files on save, so the directory is watched
+	dir := filepath.Dir(m.redirectsFile)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return watcher.Add(dir)
+	}
+	return nil
+}
+
+// watchesEvent returns true if event changes the data directory or the
+// redirects file, and not other files next to the redirects file
+func (m *Module) watchesEvent(event fsnotify.Event) bool {
+	name := filepath.Clean(event.Name)
+	if inDirs(name, []string{filepath.Clean(m.dataDir)}) {
+		return true
+	}
+	return name == filepath.Clean(m.redirectsFile)
+}
+
+// addWatchTree registers root and all of its subdirectories with watcher
+func addWatchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// renderVersionChanged returns true if the stored HTML of articles was
+// rendered by another version of the renderer, e.g. before the components
+// of the theme changed
+func (m *Module) renderVersionChanged() bool {
+	version := m.render.renderer.Version()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return version != m.renderVersion
+}
+
+// reindexAndLog runs reindex and reports the outcome
+func (m *Module) reindexAndLog(ctx context.Context) {
+	updated, removed, err := m.reindex(ctx)
+	if err != nil {
+		log.Printf("[blog] reindex failed: %v", err)
+	}
+	if updated > 0 || removed > 0 {
+		log.Printf("[blog] reindexed %s: %d updated, %d removed", m.dataDir, updated, removed)
+	}
+}
+
+// reindex compares the data directory with the last indexed state. New and
+// modified files are parsed and upserted, articles for deleted files are removed.
+// When a data file changes, all articles in its directory tree are reindexed,
+// and when the renderer version changes, all articles are, as they store the
+// rendered HTML. A file that fails to parse is logged and skipped, so one bad
+// save does not block other updates.
+func (m *Module) reindex(ctx context.Context) (updated int, removed int, err error) {
+	current, err := m.listSourceFiles()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	version := m.render.renderer.Version()
+	m.mu.Lock()
+	rerender := version != m.renderVersion
+	m.mu.Unlock()
+
+	// The redirects file is outside the data directory, and a change to it
+	// only triggers a reindex, so it's read again on every reindex
+	if err := m.indexRedirects(ctx); err != nil {
+		log.Printf("[blog] %v", err)
+	}
+
+	m.mu.Lock()
+	previous := make(map[string]fileState, len(m.files))
+	for path, state := range m.files {
+		previous[path] = state
+	}
+	m.mu.Unlock()
+
+	changed := func(path string) bool {
+		old, ok := previous[path]
+		state, exists := current[path]
+		return ok != exists || old.size != state.size || !old.modTime.Equal(state.modTime)
+	}
+
+	// Directories with added, modified or removed data files
+	var dataDirs []string
+	for path := range mergeKeys(previous, current) {
+		if isDataFile(path) && changed(path) {
+			dataDirs = append(dataDirs, filepath.Dir(path))
+		}
+	}
+
+	cascade := newDataCascade(m.dataDir)
+	for _, path := range sortedKeys(current) {
+		state := current[path]
+		if isMarkdownFile(path) && (rerender || changed(path) || inDirs(path, dataDirs)) {
+			if err := m.indexFile(ctx, cascade, path); err != nil {
+				log.Printf("[blog] %v", err)
+			} else {
+				updated++
+			}
+		}
+
+		m.mu.Lock()
+		m.files[path] = state
+		m.mu.Unlock()
+	}
+
+	for _, path := range sortedKeys(previous) {
+		if _, ok := current[path]; ok {
+			continue
+		}
+
+		if isMarkdownFile(path) {
+			if err := m.removeFile(ctx, path); err != nil {
+				return updated, removed, err
+			}
+			removed++
+		}
+
+		m.mu.Lock()
+		delete(m.files, path)
+		m.mu.Unlock()
+	}
+
+	if rerender {
+		m.mu.Lock()
+		m.renderVersion = version
+		m.mu.Unlock()
+
+		// The HTML of the previous version isn't read again
+		if err := m.pruneRenderCache(ctx); err != nil {
+			log.Printf("[blog] can't prune rendered html: %v", err)
+		}
+	}
+
+	return updated, removed, nil
+}
+
+// mergeKeys returns the union of the file paths in a and b
+func mergeKeys(a, b map[string]fileState) map[string]fileState {
+	result := make(map[string]fileState, len(a)+len(b))
+	for key, state := range a {
+		result[key] = state
+	}
+	for key, state := range b {
+		result[key] = state
+	}
+	return result
+}
+
+// inDirs returns true if path is located in any of dirs or their subdirectories
+func inDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the file paths of files in lexical order
+func sortedKeys(files map[string]fileState) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
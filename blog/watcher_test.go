@@ -0,0 +1,146 @@
+package blog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/platform-example/blog/storage"
+)
+
+// newTestModule creates a module over dataDir backed by a temporary SQLite database
+func newTestModule(t *testing.T, dataDir string) *Module {
+	db, err := sqlx.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repo := storage.NewStorage(db)
+	require.NoError(t, repo.InitSchema(context.Background()))
+
+	m := NewModule(dataDir)
+	m.SetRepository(repo)
+	return m
+}
+
+func writeArticle(t *testing.T, path, title string, stamp time.Time) {
+	content := "---\ntitle: " + title + "\ndate: 2024-01-15\n---\n\nBody of " + title + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	require.NoError(t, os.Chtimes(path, stamp, stamp))
+}
+
+func TestModuleReindex(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	stamp := time.Now().Add(-time.Hour)
+
+	writeArticle(t, filepath.Join(dataDir, "first.md"), "First", stamp)
+	writeArticle(t, filepath.Join(dataDir, "second.md"), "Second", stamp)
+
+	m := newTestModule(t, dataDir)
+	count, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	t.Run("no changes", func(t *testing.T) {
+		updated, removed, err := m.reindex(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, updated)
+		require.Equal(t, 0, removed)
+	})
+
+	t.Run("modified and new files", func(t *testing.T) {
+		writeArticle(t, filepath.Join(dataDir, "first.md"), "First (edited)", stamp.Add(time.Minute))
+		require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "nested"), 0o755))
+		writeArticle(t, filepath.Join(dataDir, "nested", "third.md"), "Third", stamp)
+
+		updated, removed, err := m.reindex(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, updated)
+		require.Equal(t, 0, removed)
+
+		article, err := m.repository.GetArticleBySlug(ctx, "first")
+		require.NoError(t, err)
+		require.Equal(t, "First (edited)", article.Title)
+
+		_, err = m.repository.GetArticleBySlug(ctx, "third")
+		require.NoError(t, err)
+	})
+
+	t.Run("deleted file", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(dataDir, "second.md")))
+
+		updated, removed, err := m.reindex(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, updated)
+		require.Equal(t, 1, removed)
+
+		_, err = m.repository.GetArticleBySlug(ctx, "second")
+		require.Error(t, err)
+		require.NotContains(t, m.articles, "second")
+
+		total, err := m.repository.CountArticles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+	})
+
+	t.Run("deleted while stopped", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(dataDir, "first.md")))
+
+		// A new module starts from the stored articles
+		restarted := NewModule(dataDir)
+		restarted.SetRepository(m.repository)
+		count, err := restarted.ScanMarkdownFiles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		_, err = restarted.repository.GetArticleBySlug(ctx, "first")
+		require.Error(t, err)
+
+		total, err := restarted.repository.CountArticles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, total)
+	})
+}
+
+func TestModuleWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dataDir := t.TempDir()
+	m := newTestModule(t, dataDir)
+	m.redirectsFile = filepath.Join(t.TempDir(), "redirects.yml")
+	_, err := m.ScanMarkdownFiles(ctx)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.watch(ctx)
+	}()
+
+	// Give the watcher time to register the directory
+	time.Sleep(100 * time.Millisecond)
+	writeArticle(t, filepath.Join(dataDir, "live.md"), "Live", time.Now())
+
+	require.Eventually(t, func() bool {
+		_, err := m.repository.GetArticleBySlug(ctx, "live")
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The redirects file is outside the data directory
+	writeFile(t, m.redirectsFile, "/about/: /resume/\n")
+
+	require.Eventually(t, func() bool {
+		redirect, err := m.repository.GetRedirect(ctx, "/about/")
+		return err == nil && redirect.Target == "/resume/"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	<-done
+}
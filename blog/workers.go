@@ -0,0 +1,65 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// workers runs jobs on a limited number of goroutines, collecting the
+// errors of all failed jobs instead of stopping at the first one
+type workers struct {
+	limit chan struct{}
+	wg    sync.WaitGroup
+
+	mu     sync.Mutex
+	errors map[string]error
+}
+
+// newWorkers returns workers running up to n jobs at the same time
+func newWorkers(n int) *workers {
+	if n < 1 {
+		n = 1
+	}
+	return &workers{
+		limit:  make(chan struct{}, n),
+		errors: make(map[string]error),
+	}
+}
+
+// Go runs the job identified by name, waiting for a free worker first
+func (w *workers) Go(name string, job func() error) {
+	w.limit <- struct{}{}
+	w.wg.Go(func() {
+		defer func() { <-w.limit }()
+
+		if err := job(); err != nil {
+			w.mu.Lock()
+			w.errors[name] = err
+			w.mu.Unlock()
+		}
+	})
+}
+
+// Wait waits for the started jobs, and returns the errors of the failed
+// jobs ordered by name
+func (w *workers) Wait() error {
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make([]string, 0, len(w.errors))
+	for name := range w.errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s: %w", name, w.errors[name]))
+	}
+	clear(w.errors)
+	return errors.Join(errs...)
+}
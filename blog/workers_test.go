@@ -0,0 +1,40 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkers(t *testing.T) {
+	w := newWorkers(3)
+
+	var running, peak atomic.Int32
+	for i := range 20 {
+		name := fmt.Sprintf("page-%02d", i)
+		w.Go(name, func() error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				current := peak.Load()
+				if n <= current || peak.CompareAndSwap(current, n) {
+					break
+				}
+			}
+
+			if i%7 == 3 {
+				return errors.New("render failed")
+			}
+			return nil
+		})
+	}
+
+	err := w.Wait()
+	require.LessOrEqual(t, peak.Load(), int32(3))
+	require.EqualError(t, err, "page-03: render failed\npage-10: render failed\npage-17: render failed")
+
+	require.NoError(t, w.Wait(), "errors are reported once")
+}